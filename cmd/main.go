@@ -1,17 +1,19 @@
 /*
-The main entry point should call the app package to start the application.
+The main entry point should call the cli package to run the command given on the command line,
+the api server is started when no command is given.
 */
 package main
 
 import (
-	"log"
+	"log/slog"
+	"os"
 
-	"github.com/ayehia0/org/pkg"
+	"github.com/ayehia0/org/pkg/cli"
 )
 
 func main() {
-	err := pkg.StartApp()
-	if err != nil {
-		log.Fatalf("could not start the app: %v", err)
+	if err := cli.Run(os.Args[1:]); err != nil {
+		slog.Error("failed to run the command", "error", err)
+		os.Exit(1)
 	}
 }
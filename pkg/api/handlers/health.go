@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	types "github.com/ayehia0/org/pkg/api"
+	"github.com/ayehia0/org/pkg/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	healthController controllers.HealthController
+}
+
+func NewHealthHandler(appC *types.AppC) *HealthHandler {
+	healthController := controllers.NewHealthController(appC)
+	return &HealthHandler{healthController: healthController}
+}
+
+func (h *HealthHandler) LivenessHandler(ctx *gin.Context) {
+	h.healthController.LivenessController(ctx)
+}
+
+func (h *HealthHandler) ReadinessHandler(ctx *gin.Context) {
+	h.healthController.ReadinessController(ctx)
+}
+
+func (h *HealthHandler) VersionHandler(ctx *gin.Context) {
+	h.healthController.VersionController(ctx)
+}
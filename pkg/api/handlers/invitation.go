@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	types "github.com/ayehia0/org/pkg/api"
+	"github.com/ayehia0/org/pkg/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+type InvitationHandler struct {
+	invitationController controllers.InvitationController
+}
+
+func NewInvitationHandler(appC *types.AppC) *InvitationHandler {
+	invitationController := controllers.NewInvitationController(appC)
+	return &InvitationHandler{invitationController: invitationController}
+}
+
+func (i *InvitationHandler) ListInvitationsHandler(ctx *gin.Context) {
+	i.invitationController.ListInvitationsController(ctx)
+}
+
+func (i *InvitationHandler) AcceptInvitationHandler(ctx *gin.Context) {
+	i.invitationController.AcceptInvitationController(ctx)
+}
+
+func (i *InvitationHandler) AcceptInvitationByTokenHandler(ctx *gin.Context) {
+	i.invitationController.AcceptInvitationByTokenController(ctx)
+}
+
+func (i *InvitationHandler) DeclineInvitationHandler(ctx *gin.Context) {
+	i.invitationController.DeclineInvitationController(ctx)
+}
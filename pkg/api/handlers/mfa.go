@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	types "github.com/ayehia0/org/pkg/api"
+	"github.com/ayehia0/org/pkg/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	mfaController controllers.MFAController
+}
+
+func NewMFAHandler(appC *types.AppC) *MFAHandler {
+	mfaController := controllers.NewMFAController(appC)
+	return &MFAHandler{mfaController: mfaController}
+}
+
+func (m *MFAHandler) EnrollMFAHandler(ctx *gin.Context) {
+	m.mfaController.EnrollMFAController(ctx)
+}
+
+func (m *MFAHandler) ConfirmMFAHandler(ctx *gin.Context) {
+	m.mfaController.ConfirmMFAController(ctx)
+}
+
+func (m *MFAHandler) DisableMFAHandler(ctx *gin.Context) {
+	m.mfaController.DisableMFAController(ctx)
+}
+
+func (m *MFAHandler) ResetRecoveryCodesHandler(ctx *gin.Context) {
+	m.mfaController.ResetRecoveryCodesController(ctx)
+}
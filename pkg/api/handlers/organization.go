@@ -38,3 +38,27 @@ func (o *OrgHandler) GetOrganizationByIDHandler(ctx *gin.Context) {
 func (o *OrgHandler) InviteUserToOrganizationHandler(ctx *gin.Context) {
 	o.orgController.InviteUserToOrganizationController(ctx)
 }
+
+func (o *OrgHandler) RemoveMemberHandler(ctx *gin.Context) {
+	o.orgController.RemoveMemberController(ctx)
+}
+
+func (o *OrgHandler) LeaveOrganizationHandler(ctx *gin.Context) {
+	o.orgController.LeaveOrganizationController(ctx)
+}
+
+func (o *OrgHandler) UpdateMemberAccessLevelHandler(ctx *gin.Context) {
+	o.orgController.UpdateMemberAccessLevelController(ctx)
+}
+
+func (o *OrgHandler) TransferOwnershipHandler(ctx *gin.Context) {
+	o.orgController.TransferOwnershipController(ctx)
+}
+
+func (o *OrgHandler) AcceptTransferHandler(ctx *gin.Context) {
+	o.orgController.AcceptTransferController(ctx)
+}
+
+func (o *OrgHandler) DeclineTransferHandler(ctx *gin.Context) {
+	o.orgController.DeclineTransferController(ctx)
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	types "github.com/ayehia0/org/pkg/api"
+	"github.com/ayehia0/org/pkg/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+type PasswordHandler struct {
+	passwordController controllers.PasswordController
+}
+
+func NewPasswordHandler(appC *types.AppC) *PasswordHandler {
+	passwordController := controllers.NewPasswordController(appC)
+	return &PasswordHandler{passwordController: passwordController}
+}
+
+func (p *PasswordHandler) ForgotPasswordHandler(ctx *gin.Context) {
+	p.passwordController.ForgotPasswordController(ctx)
+}
+
+func (p *PasswordHandler) ResetPasswordHandler(ctx *gin.Context) {
+	p.passwordController.ResetPasswordController(ctx)
+}
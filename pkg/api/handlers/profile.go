@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	types "github.com/ayehia0/org/pkg/api"
+	"github.com/ayehia0/org/pkg/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+type ProfileHandler struct {
+	profileController controllers.ProfileController
+}
+
+func NewProfileHandler(appC *types.AppC) *ProfileHandler {
+	profileController := controllers.NewProfileController(appC)
+	return &ProfileHandler{profileController: profileController}
+}
+
+func (p *ProfileHandler) GetProfileHandler(ctx *gin.Context) {
+	p.profileController.GetProfileController(ctx)
+}
+
+func (p *ProfileHandler) UpdateProfileHandler(ctx *gin.Context) {
+	p.profileController.UpdateProfileController(ctx)
+}
+
+func (p *ProfileHandler) ChangePasswordHandler(ctx *gin.Context) {
+	p.profileController.ChangePasswordController(ctx)
+}
+
+func (p *ProfileHandler) DeleteAccountHandler(ctx *gin.Context) {
+	p.profileController.DeleteAccountController(ctx)
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	types "github.com/ayehia0/org/pkg/api"
+	"github.com/ayehia0/org/pkg/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	sessionController controllers.SessionController
+}
+
+func NewSessionHandler(appC *types.AppC) *SessionHandler {
+	sessionController := controllers.NewSessionController(appC)
+	return &SessionHandler{sessionController: sessionController}
+}
+
+func (s *SessionHandler) ListSessionsHandler(ctx *gin.Context) {
+	s.sessionController.ListSessionsController(ctx)
+}
+
+func (s *SessionHandler) GetSessionHandler(ctx *gin.Context) {
+	s.sessionController.GetSessionController(ctx)
+}
+
+func (s *SessionHandler) DeleteSessionHandler(ctx *gin.Context) {
+	s.sessionController.DeleteSessionController(ctx)
+}
+
+func (s *SessionHandler) LogoutAllHandler(ctx *gin.Context) {
+	s.sessionController.LogoutAllController(ctx)
+}
@@ -24,6 +24,10 @@ func (u *UserHandler) LoginHandler(ctx *gin.Context) {
 	u.userController.LoginController(ctx)
 }
 
+func (u *UserHandler) LoginMFAHandler(ctx *gin.Context) {
+	u.userController.LoginMFAController(ctx)
+}
+
 func (u *UserHandler) RefreshTokenHandler(ctx *gin.Context) {
 	u.userController.RefreshTokenController(ctx)
 }
@@ -31,3 +35,15 @@ func (u *UserHandler) RefreshTokenHandler(ctx *gin.Context) {
 func (u *UserHandler) RevokeRefreshTokenHandler(ctx *gin.Context) {
 	u.userController.RevokeRefreshTokenController(ctx)
 }
+
+func (u *UserHandler) LogoutHandler(ctx *gin.Context) {
+	u.userController.LogoutController(ctx)
+}
+
+func (u *UserHandler) VerifyEmailHandler(ctx *gin.Context) {
+	u.userController.VerifyEmailController(ctx)
+}
+
+func (u *UserHandler) ResendVerificationHandler(ctx *gin.Context) {
+	u.userController.ResendVerificationController(ctx)
+}
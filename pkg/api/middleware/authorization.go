@@ -1,13 +1,15 @@
 package api
 
 import (
-	"errors"
 	"fmt"
-	"net/http"
+	"log/slog"
 	"strings"
+	"time"
 
+	"github.com/ayehia0/org/pkg/apperror"
+	dbrepository "github.com/ayehia0/org/pkg/database/mongodb/repository"
+	"github.com/ayehia0/org/pkg/database/redis/repository"
 	"github.com/ayehia0/org/pkg/token"
-	"github.com/ayehia0/org/pkg/utils"
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,43 +19,68 @@ var (
 
 	// to be able to store the token in the context, so we can access it later
 	AuthPayloadKey = "authorization_payload_ctx"
+
+	// the last use of a session is written at most once per interval, not on every request
+	SessionTouchInterval = time.Minute
+
+	TokenRevokedError = apperror.New(apperror.KindUnauthorized, "token_revoked", "Token has been revoked!")
+
+	ErrAuthorizationMissing     = apperror.New(apperror.KindUnauthorized, "authorization_missing", "Authentication header is empty")
+	ErrAuthorizationInvalid     = apperror.New(apperror.KindUnauthorized, "authorization_invalid", "Invalid authentication header format")
+	ErrAuthorizationUnsupported = apperror.New(apperror.KindUnauthorized, "authorization_unsupported", "Unspported authorization type")
 )
 
-func AuthMiddleware(tokenCreator token.TokenCreator) gin.HandlerFunc {
+func AuthMiddleware(tokenCreator token.TokenCreator, revocations repository.RevocationRepository, sessions dbrepository.SessionRepository) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// implement the authentication here
 		// check the header : authentication
 		authHeader := ctx.Request.Header.Get(authorizationHeaderKey)
 		if len(authHeader) == 0 {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized,
-				utils.ErrorResp(errors.New("Authentication header is empty")),
-			)
+			Abort(ctx, ErrAuthorizationMissing)
 			return
 		}
 		// get the token
 		authFields := strings.Fields(authHeader)
 
 		if len(authFields) < 2 {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized,
-				utils.ErrorResp(errors.New("Invalid authentication header format")),
-			)
+			Abort(ctx, ErrAuthorizationInvalid)
 			return
 		}
 
 		// verifiy the token
 		authType := strings.ToLower(authFields[0])
 		if authType != authorizationType {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized,
-				utils.ErrorResp(fmt.Errorf("Unspported authorization type %s", authType)),
-			)
+			Abort(ctx, ErrAuthorizationUnsupported.WithMessage(fmt.Sprintf("Unspported authorization type %s", authType)))
 			return
 		}
 
 		payload, err := tokenCreator.Verify(authFields[1])
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, utils.ErrorResp(err))
+			Abort(ctx, err)
+			return
+		}
+
+		// tokens issued for a specific purpose (invites, ...) can't be used to authenticate
+		if payload.Purpose != "" {
+			Abort(ctx, token.TokenInvalidError)
+			return
+		}
+
+		// the token might have been revoked before its expiration (logout, ...)
+		revoked, err := revocations.IsRevoked(ctx, payload.Id.String())
+		if err != nil {
+			Abort(ctx, apperror.Internal("failed to check the token revocation", err))
 			return
 		}
+		if revoked {
+			Abort(ctx, TokenRevokedError)
+			return
+		}
+		// the session of the token (if any) has been used now, the request isn't failed when it can't be recorded
+		if err := sessions.Touch(ctx, payload.Id.String(), time.Now(), SessionTouchInterval); err != nil {
+			slog.WarnContext(ctx, "failed to record the last use of the session", "error", err)
+		}
+
 		ctx.Set(AuthPayloadKey, payload)
 		ctx.Next()
 	}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/ayehia0/org/pkg/apperror"
+	"github.com/ayehia0/org/pkg/logger"
+	"github.com/ayehia0/org/pkg/token"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// the header carrying the id of the request, it's kept when the client (or the proxy) sends one
+const RequestIDHeader = "X-Request-ID"
+
+// the longest request id accepted from the clients
+const maxRequestIDLength = 128
+
+// the middleware giving every request an id, the id is returned in the response and attached to the logs of the request
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		ctx.Header(RequestIDHeader, id)
+		ctx.Request = ctx.Request.WithContext(logger.WithRequestID(ctx.Request.Context(), id))
+		ctx.Next()
+	}
+}
+
+// the ids of the clients end up in the logs, so only the short printable ones are kept
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// the middleware writing a log for every request, the query isn't logged since it might carry tokens
+func AccessLogMiddleware(log *slog.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", ctx.Request.Method),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.String("route", ctx.FullPath()),
+			slog.Int("status", status),
+			slog.Int("bytes", ctx.Writer.Size()),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("ip", ctx.ClientIP()),
+			slog.String("user_agent", ctx.Request.UserAgent()),
+		}
+
+		// the user is known once the auth middleware has verified the token
+		if value, ok := ctx.Get(AuthPayloadKey); ok {
+			if payload, ok := value.(*token.Payload); ok {
+				attrs = append(attrs, slog.String("user_id", payload.UserId))
+			}
+		}
+		if len(ctx.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", ctx.Errors.String()))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		log.LogAttrs(ctx, level, "request", attrs...)
+	}
+}
+
+// the middleware recovering from the panics of the handlers, the panic is logged with its stack instead of being printed by gin
+func RecoveryMiddleware(log *slog.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(ctx *gin.Context, recovered any) {
+		log.ErrorContext(ctx, "panic recovered", "panic", recovered, "stack", string(debug.Stack()))
+		Abort(ctx, apperror.Internal("internal server error", fmt.Errorf("panic: %v", recovered)))
+	})
+}
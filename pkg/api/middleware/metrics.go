@@ -0,0 +1,24 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/ayehia0/org/pkg/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// the middleware counting the requests and their latency per route and status
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		// the requests that don't match any route share the same series
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveRequest(ctx.Request.Method, route, strconv.Itoa(ctx.Writer.Status()), time.Since(start))
+	}
+}
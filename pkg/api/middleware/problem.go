@@ -0,0 +1,131 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/ayehia0/org/pkg/apperror"
+	"github.com/ayehia0/org/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// the content type of the error responses
+const ProblemContentType = "application/problem+json"
+
+var ErrRouteNotFound = apperror.New(apperror.KindNotFound, "route_not_found", "route not found")
+
+// the error responses follow the problem details (RFC 7807), code and request_id are extensions
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// a field of the request that didn't pass the validation
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// the http status of each kind of error
+var kindStatus = map[apperror.Kind]int{
+	apperror.KindInternal:        http.StatusInternalServerError,
+	apperror.KindInvalid:         http.StatusBadRequest,
+	apperror.KindUnauthorized:    http.StatusUnauthorized,
+	apperror.KindForbidden:       http.StatusForbidden,
+	apperror.KindNotFound:        http.StatusNotFound,
+	apperror.KindConflict:        http.StatusConflict,
+	apperror.KindGone:            http.StatusGone,
+	apperror.KindTooManyRequests: http.StatusTooManyRequests,
+}
+
+func init() {
+	// the validation errors name the fields the way the clients send them
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			for _, tag := range []string{"json", "form", "uri"} {
+				name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+				if name == "-" {
+					return ""
+				}
+				if name != "" {
+					return name
+				}
+			}
+			return field.Name
+		})
+	}
+}
+
+// abort the request with an error, the response is written by the error middleware
+func Abort(ctx *gin.Context, err error) {
+	_ = ctx.Error(err)
+	ctx.Abort()
+}
+
+// the middleware answering the errors of the handlers (ctx.Error) with a problem, the internal errors are logged and their cause is hidden
+func ErrorMiddleware(log *slog.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		// the handler has already answered
+		if len(ctx.Errors) == 0 || ctx.Writer.Written() {
+			return
+		}
+
+		err := ctx.Errors.Last().Err
+		appErr := apperror.From(err)
+		status, ok := kindStatus[appErr.Kind]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+		if status >= http.StatusInternalServerError {
+			log.ErrorContext(ctx, "request failed", "error_code", appErr.Code, "error", err)
+		}
+
+		problem := Problem{
+			Type:      "about:blank",
+			Title:     http.StatusText(status),
+			Status:    status,
+			Detail:    appErr.Message,
+			Instance:  ctx.Request.URL.Path,
+			Code:      appErr.Code,
+			RequestID: logger.RequestID(ctx),
+		}
+		if appErr.Code == apperror.CodeInvalidRequest {
+			problem.Errors = fieldErrors(appErr.Err)
+		}
+
+		ctx.Header("Content-Type", ProblemContentType)
+		ctx.JSON(status, problem)
+	}
+}
+
+// the fields that didn't pass the validation or don't have the expected type
+func fieldErrors(err error) []FieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, FieldError{Field: fe.Field(), Reason: fe.Tag()})
+		}
+		return fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []FieldError{{Field: typeErr.Field, Reason: "type"}}
+	}
+	return nil
+}
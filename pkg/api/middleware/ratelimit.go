@@ -0,0 +1,114 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ayehia0/org/pkg/apperror"
+	"github.com/ayehia0/org/pkg/database/redis/repository"
+	"github.com/ayehia0/org/pkg/token"
+	"github.com/ayehia0/org/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+var TooManyRequestsError = apperror.New(apperror.KindTooManyRequests, "rate_limited", "too many requests, retry later")
+
+// the most of the body read to find the email or the refresh token, the rest is left to the handler
+const maxPeekedBody = 1 << 20
+
+// the fields of the auth requests the limits are keyed by
+type limitedRequest struct {
+	Email        string `json:"email"`
+	RefreshToken string `json:"refresh_token"`
+	MFAToken     string `json:"mfa_token"`
+}
+
+// the middleware limiting the requests of an endpoint per ip, per email and per user, the name separates the counters of the endpoints
+// the requests are let through when the limits can't be checked, the auth doesn't depend on the availability of redis
+func RateLimitMiddleware(limiter repository.RateLimitRepository, tokenCreator token.TokenCreator, name string, rule utils.RateLimitRule) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		limits := map[string]utils.RateLimit{}
+
+		if rule.IP.Limit > 0 {
+			limits[name+":ip:"+ctx.ClientIP()] = rule.IP
+		}
+
+		if rule.Email.Limit > 0 || rule.User.Limit > 0 {
+			req := peekRequest(ctx)
+			if rule.Email.Limit > 0 && req.Email != "" {
+				limits[name+":email:"+strings.ToLower(strings.TrimSpace(req.Email))] = rule.Email
+			}
+			if rule.User.Limit > 0 {
+				if userID := requestUser(tokenCreator, req); userID != "" {
+					limits[name+":user:"+userID] = rule.User
+				}
+			}
+		}
+
+		for key, limit := range limits {
+			allowed, retryAfter, err := limiter.Allow(ctx, key, limit.Limit, limit.Window)
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to check the rate limit", "key", key, "error", err)
+				continue
+			}
+			if !allowed {
+				TooManyRequests(ctx, retryAfter, TooManyRequestsError)
+				return
+			}
+		}
+
+		ctx.Next()
+	}
+}
+
+// read the fields of the body the limits are keyed by, the body is put back for the handler
+func peekRequest(ctx *gin.Context) limitedRequest {
+	var req limitedRequest
+	if ctx.Request.Body == nil {
+		return req
+	}
+
+	data, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxPeekedBody))
+	ctx.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), ctx.Request.Body), ctx.Request.Body}
+	if err != nil {
+		return req
+	}
+
+	// the malformed bodies are rejected by the handler
+	_ = json.Unmarshal(data, &req)
+	return req
+}
+
+// the user is only known from a valid refresh token or mfa token, empty otherwise
+func requestUser(tokenCreator token.TokenCreator, req limitedRequest) string {
+	if req.RefreshToken != "" {
+		if payload, err := tokenCreator.Verify(req.RefreshToken); err == nil && payload.Purpose == token.PurposeRefresh {
+			return payload.UserId
+		}
+	}
+	if req.MFAToken != "" {
+		if payload, err := tokenCreator.Verify(req.MFAToken); err == nil && payload.Purpose == token.PurposeMFA {
+			return payload.UserId
+		}
+	}
+	return ""
+}
+
+// abort with the error (429) and tell the client when to retry (in seconds, rounded up)
+func TooManyRequests(ctx *gin.Context, retryAfter time.Duration, err error) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	ctx.Header("Retry-After", strconv.Itoa(seconds))
+	Abort(ctx, err)
+}
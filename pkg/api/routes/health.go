@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/ayehia0/org/pkg/api/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// here we define the routes probed by the orchestrator, they don't need authentication
+func SetupHealthRoutes(router *gin.RouterGroup, healthHandler *handlers.HealthHandler) {
+	router.GET("/healthz", healthHandler.LivenessHandler)
+	router.GET("/readyz", healthHandler.ReadinessHandler)
+	router.GET("/version", healthHandler.VersionHandler)
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/ayehia0/org/pkg/api/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// here we define all the routes for the invitee side of the invitations
+func SetupInvitationRoutes(router *gin.RouterGroup, invitationHandler *handlers.InvitationHandler) {
+	router.GET("/", invitationHandler.ListInvitationsHandler)
+	router.POST("/accept", invitationHandler.AcceptInvitationByTokenHandler)
+	router.POST("/:id/accept", invitationHandler.AcceptInvitationHandler)
+	router.POST("/:id/decline", invitationHandler.DeclineInvitationHandler)
+}
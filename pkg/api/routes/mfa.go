@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/ayehia0/org/pkg/api/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// here we define all the routes for the two factor authentication of the authenticated user
+func SetupMFARoutes(router *gin.RouterGroup, mfaHandler *handlers.MFAHandler) {
+	router.POST("/me/mfa/enroll", mfaHandler.EnrollMFAHandler)
+	router.POST("/me/mfa/confirm", mfaHandler.ConfirmMFAHandler)
+	router.POST("/me/mfa/disable", mfaHandler.DisableMFAHandler)
+	router.POST("/me/mfa/recovery-codes", mfaHandler.ResetRecoveryCodesHandler)
+}
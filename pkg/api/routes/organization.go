@@ -13,4 +13,10 @@ func SetupOrgRoutes(router *gin.RouterGroup, orgHandler *handlers.OrgHandler) {
 	router.PUT("/:id", orgHandler.UpdateOrganizationHandler)
 	router.GET("/", orgHandler.GetAllOrganizationsHandler)
 	router.GET("/:id", orgHandler.GetOrganizationByIDHandler)
+	router.POST("/:id/leave", orgHandler.LeaveOrganizationHandler)
+	router.PUT("/:id/members/:member_id", orgHandler.UpdateMemberAccessLevelHandler)
+	router.DELETE("/:id/members/:member_id", orgHandler.RemoveMemberHandler)
+	router.POST("/:id/transfer", orgHandler.TransferOwnershipHandler)
+	router.POST("/:id/transfer/accept", orgHandler.AcceptTransferHandler)
+	router.DELETE("/:id/transfer", orgHandler.DeclineTransferHandler)
 }
@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"github.com/ayehia0/org/pkg/api/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// here we define all the routes to recover an account
+func SetupPasswordRoutes(router *gin.RouterGroup, passwordHandler *handlers.PasswordHandler) {
+	router.POST("/forgot", passwordHandler.ForgotPasswordHandler)
+	router.POST("/reset", passwordHandler.ResetPasswordHandler)
+}
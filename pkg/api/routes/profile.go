@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/ayehia0/org/pkg/api/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// here we define all the routes for the account of the authenticated user
+func SetupProfileRoutes(router *gin.RouterGroup, profileHandler *handlers.ProfileHandler) {
+	router.GET("/me", profileHandler.GetProfileHandler)
+	router.PATCH("/me", profileHandler.UpdateProfileHandler)
+	router.DELETE("/me", profileHandler.DeleteAccountHandler)
+	router.POST("/me/password", profileHandler.ChangePasswordHandler)
+}
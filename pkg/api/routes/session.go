@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/ayehia0/org/pkg/api/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// here we define all the routes for the sessions of the authenticated user
+func SetupSessionRoutes(router *gin.RouterGroup, sessionHandler *handlers.SessionHandler) {
+	router.GET("/me/sessions", sessionHandler.ListSessionsHandler)
+	router.GET("/me/sessions/:id", sessionHandler.GetSessionHandler)
+	router.DELETE("/me/sessions/:id", sessionHandler.DeleteSessionHandler)
+	router.POST("/logout-all", sessionHandler.LogoutAllHandler)
+}
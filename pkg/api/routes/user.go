@@ -5,10 +5,27 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// the rate limiting middlewares of the auth routes
+type AuthLimits struct {
+	Login   gin.HandlerFunc
+	Signup  gin.HandlerFunc
+	Refresh gin.HandlerFunc
+	MFA     gin.HandlerFunc
+}
+
 // here we define all the routes for user business logic
-func SetupUserRoutes(router *gin.RouterGroup, userHandler *handlers.UserHandler) {
-	router.POST("/signup", userHandler.SignupHandler)
-	router.POST("/login", userHandler.LoginHandler)
-	router.POST("/refresh-token", userHandler.RefreshTokenHandler)
+func SetupUserRoutes(router *gin.RouterGroup, userHandler *handlers.UserHandler, limits AuthLimits) {
+	router.POST("/signup", limits.Signup, userHandler.SignupHandler)
+	router.POST("/login", limits.Login, userHandler.LoginHandler)
+	// the codes are easy to guess, the second factor is limited per user (of the mfa token) too
+	router.POST("/login/mfa", limits.MFA, userHandler.LoginMFAHandler)
+	router.POST("/refresh-token", limits.Refresh, userHandler.RefreshTokenHandler)
 	router.POST("/revoke-refresh-token", userHandler.RevokeRefreshTokenHandler)
+	router.POST("/verify-email", userHandler.VerifyEmailHandler)
+	router.POST("/verify-email/resend", userHandler.ResendVerificationHandler)
+}
+
+// the user routes that require an access token
+func SetupAuthenticatedUserRoutes(router *gin.RouterGroup, userHandler *handlers.UserHandler) {
+	router.POST("/logout", userHandler.LogoutHandler)
 }
@@ -8,6 +8,10 @@ This package is responsible for starting the application and setting up the nece
 	- Utils: like logger, config, etc.
 */
 
+import (
+	"context"
+)
+
 // export a interface type to represent the application
 type App interface {
 	Start() error
@@ -25,6 +29,10 @@ func NewApp(server *Server) App {
 // the function to start the app
 func (a *app) Start() error {
 	if err := a.Server.Init(); err != nil {
+		// the databases might already be connected
+		if a.Server.Driver != nil {
+			a.Server.Close()
+		}
 		return err
 	}
 	return a.Server.Run()
@@ -36,3 +44,19 @@ func StartApp() error {
 	app := NewApp(server)
 	return app.Start()
 }
+
+// the function to apply the pending migrations without starting the server
+func MigrateApp() error {
+	server := NewServer()
+	if err := server.Open(); err != nil {
+		return err
+	}
+	defer server.Close()
+
+	count, err := server.Driver.Migrate(context.Background())
+	if err != nil {
+		return err
+	}
+	server.Logger.Info("applied the migrations", "count", count)
+	return nil
+}
@@ -0,0 +1,98 @@
+package apperror
+
+/*
+The apperror package contains the typed errors of the application, they are returned by the repositories and the controllers
+and written as problem details (RFC 7807) by the error middleware:
+	- the kind tells the http status of the response
+	- the code is stable, the clients can rely on it
+	- the message is safe to show to the clients, the cause is only logged
+*/
+
+import (
+	"errors"
+	"fmt"
+)
+
+// the kinds of errors, each kind is answered with its own http status
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindInvalid
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindGone
+	KindTooManyRequests
+)
+
+// the codes shared by the whole application
+const (
+	CodeInternal       = "internal_error"
+	CodeInvalidRequest = "invalid_request"
+)
+
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+
+	// the cause of the error, never shown to the clients
+	Err error
+}
+
+// create an error, the errors are usually declared once as variables and returned (or wrapped) as they are
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// an internal error, the message tells what failed without leaking the cause
+func Internal(message string, err error) *Error {
+	return &Error{Kind: KindInternal, Code: CodeInternal, Message: message, Err: err}
+}
+
+// the request can't be bound or doesn't pass the validation
+func InvalidRequest(err error) *Error {
+	return &Error{Kind: KindInvalid, Code: CodeInvalidRequest, Message: "the request is invalid", Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// two errors are the same when they have the same code, so errors.Is matches the wrapped errors and the ones with another message
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// a copy of the error caused by err
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+// a copy of the error with another message, the code stays the same
+func (e *Error) WithMessage(message string) *Error {
+	copied := *e
+	copied.Message = message
+	return &copied
+}
+
+// get the typed error of err, the errors that aren't typed are internal errors
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return Internal("internal server error", err)
+}
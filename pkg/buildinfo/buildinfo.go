@@ -0,0 +1,47 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// the version and the commit are set when building:
+//
+//	go build -ldflags "-X github.com/ayehia0/org/pkg/buildinfo.Version=v1.2.0 -X github.com/ayehia0/org/pkg/buildinfo.Commit=$(git rev-parse HEAD)" ./cmd
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// the information about the running build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// the function to get the information about the running build
+// when the commit isn't set by the linker, the one recorded by the go toolchain is used
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			if setting.Key == "vcs.revision" && info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		}
+	}
+
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	return info
+}
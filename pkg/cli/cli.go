@@ -0,0 +1,127 @@
+package cli
+
+/*
+The cli package contains the commands of the operators, they reuse the configs and the stores of the server
+so nobody has to edit the database by hand.
+*/
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ayehia0/org/pkg"
+	types "github.com/ayehia0/org/pkg/api"
+	"github.com/ayehia0/org/pkg/database"
+)
+
+const usage = `usage: main <command> [flags]
+
+commands:
+  serve                                               start the api server (default)
+  migrate                                             apply the pending database migrations
+  user create --name NAME --email EMAIL [--password PASSWORD] [--verified]
+  user disable --email EMAIL                          disable a user and revoke the sessions
+  user enable --email EMAIL                           enable a disabled user
+  user reset-password --email EMAIL [--password PASSWORD]
+  org list [--member EMAIL]                           list the organizations (of a member)
+  org transfer --org ID --to EMAIL                    make a user the owner of an organization
+  org delete --org ID                                 delete an organization
+  sessions purge [--email EMAIL]                      revoke the sessions of a user or delete the expired ones
+`
+
+// a command of the operators, the args are the flags following the name of the command
+type command func(ctx context.Context, appC *types.AppC, args []string) error
+
+var commands = map[string]command{
+	"user create":         userCreate,
+	"user disable":        userDisable,
+	"user enable":         userEnable,
+	"user reset-password": userResetPassword,
+	"org list":            orgList,
+	"org transfer":        orgTransfer,
+	"org delete":          orgDelete,
+	"sessions purge":      sessionsPurge,
+}
+
+// the function to run the command given on the command line
+func Run(args []string) error {
+	if len(args) == 0 || args[0] == "serve" {
+		if err := pkg.StartApp(); err != nil {
+			return fmt.Errorf("could not start the app: %w", err)
+		}
+		return nil
+	}
+
+	switch args[0] {
+	case "migrate":
+		return pkg.MigrateApp()
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return nil
+	}
+
+	if len(args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+	name := args[0] + " " + args[1]
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command: %s", name)
+	}
+
+	// connect to the same storage backend as the server
+	server := pkg.NewServer()
+	if err := server.Open(); err != nil {
+		return err
+	}
+	defer server.Close()
+
+	// the memory driver lives in the process of the server, the command would only change its own empty store
+	if server.DBConfig.Type == database.TypeMemory {
+		return fmt.Errorf("%s can't run on the %s database type, the data only exists in the server process", name, database.TypeMemory)
+	}
+
+	appC := &types.AppC{
+		DBStore:   server.Driver.DBStore(),
+		RDBStore:  server.Driver.RedisStore(),
+		AppConfig: server.AppConfig,
+		Logger:    server.Logger,
+	}
+	return cmd(context.Background(), appC, args[2:])
+}
+
+// create the flags of a command
+func newFlags(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ContinueOnError)
+}
+
+// make sure the required flags have been given
+func required(values map[string]string) error {
+	missing := []string{}
+	for name, value := range values {
+		if value == "" {
+			missing = append(missing, "--"+name)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.New("missing " + strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// generate a random password for the operators who don't give one
+func generatePassword() (string, error) {
+	raw := make([]byte, 12)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	types "github.com/ayehia0/org/pkg/api"
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+)
+
+// list all the organizations or the ones of a member
+func orgList(ctx context.Context, appC *types.AppC, args []string) error {
+	fs := newFlags("org list")
+	member := fs.String("member", "", "only the organizations of the user with this email")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var orgs []models.Organization
+	if *member != "" {
+		user, err := appC.DBStore.UserRepository.FindByEmail(ctx, *member)
+		if err != nil {
+			return err
+		}
+		if orgs, err = appC.DBStore.OrganizationRepository.FindByMember(ctx, user.ID); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if orgs, err = appC.DBStore.OrganizationRepository.FindAll(ctx); err != nil {
+			return err
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tCREATOR\tMEMBERS")
+	for _, org := range orgs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", org.ID, org.Name, org.Creator, len(org.Members))
+	}
+	return w.Flush()
+}
+
+// make a user the owner (and the creator) of an organization, the previous creator becomes an admin
+// it goes through the same atomic transfer as the one accepted by the members
+func orgTransfer(ctx context.Context, appC *types.AppC, args []string) error {
+	fs := newFlags("org transfer")
+	orgID := fs.String("org", "", "the id of the organization")
+	to := fs.String("to", "", "the email of the new owner")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := required(map[string]string{"org": *orgID, "to": *to}); err != nil {
+		return err
+	}
+
+	org, err := appC.DBStore.OrganizationRepository.FindByID(ctx, *orgID)
+	if err != nil {
+		return err
+	}
+	user, err := appC.DBStore.UserRepository.FindByEmail(ctx, *to)
+	if err != nil {
+		return err
+	}
+	if org.Creator == user.ID {
+		return errors.New("the user is already the owner of the organization")
+	}
+
+	// the new owner must be a member for the transfer to complete
+	isMember := false
+	for _, member := range org.Members {
+		if member.ID == user.ID {
+			isMember = true
+			break
+		}
+	}
+	if !isMember {
+		err := appC.DBStore.OrganizationRepository.AddMember(ctx, org.ID, &models.Member{
+			ID:          user.ID,
+			Name:        user.Name,
+			Email:       user.Email,
+			AccessLevel: models.AccessLevelOwner,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	err = appC.DBStore.OrganizationRepository.ProposeTransfer(ctx, org.ID, &models.OwnershipTransfer{
+		From:      org.Creator,
+		To:        user.ID,
+		ExpiresAt: now,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+	if err := appC.DBStore.OrganizationRepository.CompleteTransfer(ctx, org.ID, org.Creator, user.ID); err != nil {
+		return err
+	}
+
+	fmt.Printf("transferred %s to %s\n", org.Name, user.Email)
+	return nil
+}
+
+// delete an organization
+func orgDelete(ctx context.Context, appC *types.AppC, args []string) error {
+	fs := newFlags("org delete")
+	orgID := fs.String("org", "", "the id of the organization")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := required(map[string]string{"org": *orgID}); err != nil {
+		return err
+	}
+
+	org, err := appC.DBStore.OrganizationRepository.FindByID(ctx, *orgID)
+	if err != nil {
+		return err
+	}
+	if err := appC.DBStore.OrganizationRepository.Delete(ctx, org.ID); err != nil {
+		return err
+	}
+
+	fmt.Printf("deleted %s\n", org.Name)
+	return nil
+}
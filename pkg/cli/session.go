@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	types "github.com/ayehia0/org/pkg/api"
+	"github.com/ayehia0/org/pkg/controllers"
+)
+
+// revoke all the sessions of a user, or delete the expired sessions of everyone when no user is given
+func sessionsPurge(ctx context.Context, appC *types.AppC, args []string) error {
+	fs := newFlags("sessions purge")
+	email := fs.String("email", "", "revoke the sessions of the user with this email")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *email == "" {
+		count, err := appC.DBStore.SessionRepository.DeleteExpired(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("deleted %d expired sessions\n", count)
+		return nil
+	}
+
+	user, err := appC.DBStore.UserRepository.FindByEmail(ctx, *email)
+	if err != nil {
+		return err
+	}
+	count, err := controllers.RevokeUserSessions(ctx, appC, user.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("revoked %d sessions of %s\n", count, user.Email)
+	return nil
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	types "github.com/ayehia0/org/pkg/api"
+	"github.com/ayehia0/org/pkg/controllers"
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/utils"
+)
+
+// create a user, the password is generated when it isn't given
+func userCreate(ctx context.Context, appC *types.AppC, args []string) error {
+	fs := newFlags("user create")
+	name := fs.String("name", "", "the name of the user")
+	email := fs.String("email", "", "the email of the user")
+	password := fs.String("password", "", "the password of the user, generated if empty")
+	verified := fs.Bool("verified", false, "mark the email as verified")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := required(map[string]string{"name": *name, "email": *email}); err != nil {
+		return err
+	}
+
+	generated := *password == ""
+	if generated {
+		var err error
+		if *password, err = generatePassword(); err != nil {
+			return err
+		}
+	}
+
+	hash, err := utils.GenerateHash(*password)
+	if err != nil {
+		return err
+	}
+	user := &models.User{
+		Name:          *name,
+		Email:         *email,
+		Password:      hash,
+		EmailVerified: *verified,
+	}
+	if err := appC.DBStore.UserRepository.Create(ctx, user); err != nil {
+		return err
+	}
+
+	// the user might have been invited to organizations before having an account
+	if _, err := appC.DBStore.InvitationRepository.AttachUser(ctx, user.Email, user.ID); err != nil {
+		return err
+	}
+
+	fmt.Printf("created user %s (%s)\n", user.ID, user.Email)
+	if generated {
+		fmt.Printf("password: %s\n", *password)
+	}
+	return nil
+}
+
+// disable a user and log the user out of every session
+func userDisable(ctx context.Context, appC *types.AppC, args []string) error {
+	return setDisabled(ctx, appC, "user disable", args, true)
+}
+
+// enable a disabled user
+func userEnable(ctx context.Context, appC *types.AppC, args []string) error {
+	return setDisabled(ctx, appC, "user enable", args, false)
+}
+
+func setDisabled(ctx context.Context, appC *types.AppC, name string, args []string, disabled bool) error {
+	fs := newFlags(name)
+	email := fs.String("email", "", "the email of the user")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := required(map[string]string{"email": *email}); err != nil {
+		return err
+	}
+
+	user, err := appC.DBStore.UserRepository.FindByEmail(ctx, *email)
+	if err != nil {
+		return err
+	}
+	if err := appC.DBStore.UserRepository.SetDisabled(ctx, user.ID, disabled); err != nil {
+		return err
+	}
+
+	if !disabled {
+		fmt.Printf("enabled user %s\n", user.Email)
+		return nil
+	}
+
+	count, err := controllers.RevokeUserSessions(ctx, appC, user.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("disabled user %s, %d sessions revoked\n", user.Email, count)
+	return nil
+}
+
+// replace the password of a user and revoke every session, the password is generated when it isn't given
+func userResetPassword(ctx context.Context, appC *types.AppC, args []string) error {
+	fs := newFlags("user reset-password")
+	email := fs.String("email", "", "the email of the user")
+	password := fs.String("password", "", "the new password, generated if empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := required(map[string]string{"email": *email}); err != nil {
+		return err
+	}
+
+	user, err := appC.DBStore.UserRepository.FindByEmail(ctx, *email)
+	if err != nil {
+		return err
+	}
+
+	generated := *password == ""
+	if generated {
+		if *password, err = generatePassword(); err != nil {
+			return err
+		}
+	}
+
+	hash, err := utils.GenerateHash(*password)
+	if err != nil {
+		return err
+	}
+	if err := appC.DBStore.UserRepository.UpdatePassword(ctx, user.ID, hash); err != nil {
+		return err
+	}
+
+	count, err := controllers.RevokeUserSessions(ctx, appC, user.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("reset the password of %s, %d sessions revoked\n", user.Email, count)
+	if generated {
+		fmt.Printf("password: %s\n", *password)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import "github.com/ayehia0/org/pkg/apperror"
+
+// the errors answered by the controllers, the codes are part of the api and must not change
+var (
+	// the authentication
+	ErrInvalidCredentials       = apperror.New(apperror.KindUnauthorized, "invalid_credentials", "invalid credentials")
+	ErrInvalidToken             = apperror.New(apperror.KindUnauthorized, "invalid_token", "invalid token")
+	ErrInvalidCode              = apperror.New(apperror.KindUnauthorized, "invalid_code", "invalid code")
+	ErrSessionMismatch          = apperror.New(apperror.KindUnauthorized, "session_mismatch", "Session does not belong to the user")
+	ErrSessionExpired           = apperror.New(apperror.KindUnauthorized, "session_expired", "Session has been expired before!")
+	ErrRefreshTokenReused       = apperror.New(apperror.KindUnauthorized, "refresh_token_reused", "refresh token has been reused, the session has been revoked")
+	ErrInvalidVerificationToken = apperror.New(apperror.KindUnauthorized, "invalid_verification_token", "invalid verification token")
+	ErrInvalidResetToken        = apperror.New(apperror.KindUnauthorized, "invalid_reset_token", "invalid reset token")
+	ErrResetTokenUsed           = apperror.New(apperror.KindUnauthorized, "reset_token_used", "reset token has already been used")
+	ErrInvalidInviteToken       = apperror.New(apperror.KindUnauthorized, "invalid_invite_token", "invalid invite token")
+	ErrAccountDisabled          = apperror.New(apperror.KindForbidden, "account_disabled", "account has been disabled")
+	ErrAccountLocked            = apperror.New(apperror.KindTooManyRequests, "account_locked", "account is temporarily locked, too many failed attempts")
+	ErrEmailNotVerified         = apperror.New(apperror.KindForbidden, "email_not_verified", "email hasn't been verified yet")
+
+	// the two factor authentication
+	ErrMFAAlreadyEnabled = apperror.New(apperror.KindConflict, "mfa_already_enabled", "two factor authentication is already enabled")
+	ErrMFANotEnabled     = apperror.New(apperror.KindInvalid, "mfa_not_enabled", "two factor authentication isn't enabled")
+	ErrMFANotEnrolled    = apperror.New(apperror.KindInvalid, "mfa_not_enrolled", "two factor authentication enrollment hasn't been started")
+
+	// the organizations
+	ErrPermissionDenied        = apperror.New(apperror.KindForbidden, "permission_denied", "you don't have the permission to do this action")
+	ErrCannotRemoveMember      = apperror.New(apperror.KindForbidden, "cannot_remove_member", "you can't remove a member with the same or a higher access level")
+	ErrCannotGrantAccessLevel  = apperror.New(apperror.KindForbidden, "cannot_grant_access_level", "you can't grant a higher access level than yours")
+	ErrCannotChangeAccessLevel = apperror.New(apperror.KindForbidden, "cannot_change_access_level", "you can't change the access level of this member")
+	ErrLastOwner               = apperror.New(apperror.KindConflict, "last_owner", "the last owner of the organization can't leave it")
+	ErrAlreadyOwner            = apperror.New(apperror.KindInvalid, "already_owner", "the member already owns the organization")
+	ErrAlreadyMember           = apperror.New(apperror.KindConflict, "already_member", "User is already a member of the organization")
+	ErrTransferExpired         = apperror.New(apperror.KindGone, "transfer_expired", "ownership transfer has been expired")
+
+	// the invitations
+	ErrInvitationExpired = apperror.New(apperror.KindGone, "invitation_expired", "invitation has been expired")
+	ErrInvitationClosed  = apperror.New(apperror.KindConflict, "invitation_closed", "invitation has already been closed")
+	ErrInvitationPending = apperror.New(apperror.KindConflict, "invitation_pending", "User has already a pending invitation to the organization")
+)
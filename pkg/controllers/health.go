@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	types "github.com/ayehia0/org/pkg/api"
+	"github.com/ayehia0/org/pkg/buildinfo"
+	"github.com/gin-gonic/gin"
+)
+
+// the statuses reported by the probes
+const (
+	statusOK          = "ok"
+	statusUnavailable = "unavailable"
+)
+
+// the time given to the dependencies when the readiness timeout isn't configured
+const defaultReadinessTimeout = 2 * time.Second
+
+// here we define all the controllers probed by the orchestrator
+type HealthController interface {
+	LivenessController(ctx *gin.Context)  // the process is up
+	ReadinessController(ctx *gin.Context) // the dependencies can be reached
+	VersionController(ctx *gin.Context)   // the version and the commit of the running build
+}
+
+type appH struct {
+	types.AppC
+}
+
+func NewHealthController(appC *types.AppC) HealthController {
+	return &appH{AppC: *appC}
+}
+
+func (ah *appH) LivenessController(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, HealthResponse{Status: statusOK})
+}
+
+// all the dependencies are checked at the same time, each one has the readiness timeout to answer
+func (ah *appH) ReadinessController(ctx *gin.Context) {
+	timeout := ah.AppConfig.ReadinessTimeout
+	if timeout <= 0 {
+		timeout = defaultReadinessTimeout
+	}
+
+	resp := ReadinessResponse{Status: statusOK, Checks: map[string]string{}}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for name, check := range ah.HealthChecks {
+		wg.Add(1)
+		go func(name string, check func(ctx context.Context) error) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check(checkCtx)
+			status := statusOK
+			if err != nil {
+				// the probes are public, the errors might name the hosts of the databases
+				status = statusUnavailable
+				ah.Logger.ErrorContext(ctx, "dependency is unavailable", "dependency", name,
+					"latency_ms", time.Since(start).Milliseconds(), "error", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			resp.Checks[name] = status
+			if err != nil {
+				resp.Status = statusUnavailable
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	if resp.Status != statusOK {
+		ctx.JSON(http.StatusServiceUnavailable, resp)
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (ah *appH) VersionController(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, buildinfo.Get())
+}
@@ -0,0 +1,13 @@
+package controllers
+
+// here we put all the response types for the health controller
+
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+// the status of each dependency by name, the causes of the failures are only logged
+type ReadinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
@@ -0,0 +1,28 @@
+package controllers_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ayehia0/org/pkg/controllers"
+)
+
+func TestReadinessHidesTheErrors(t *testing.T) {
+	app := newTestApp(t)
+	app.appC.HealthChecks["redis"] = func(ctx context.Context) error { return nil }
+	app.appC.HealthChecks["mongodb"] = func(ctx context.Context) error {
+		return errors.New("dial tcp mongo.internal:27017: connection refused")
+	}
+
+	rec := app.do(http.MethodGet, "/readyz", "", nil)
+	if strings.Contains(rec.Body.String(), "mongo.internal") {
+		t.Fatalf("the readiness leaks the error: %s", rec.Body.String())
+	}
+	resp := call[controllers.ReadinessResponse](app, http.MethodGet, "/readyz", "", nil, http.StatusServiceUnavailable)
+	if resp.Checks["mongodb"] != "unavailable" || resp.Checks["redis"] != "ok" {
+		t.Fatalf("unexpected checks: %+v", resp.Checks)
+	}
+}
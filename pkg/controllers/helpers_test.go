@@ -0,0 +1,164 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/ayehia0/org/pkg"
+	types "github.com/ayehia0/org/pkg/api"
+	"github.com/ayehia0/org/pkg/controllers"
+	"github.com/ayehia0/org/pkg/database"
+	"github.com/ayehia0/org/pkg/database/memory"
+	"github.com/ayehia0/org/pkg/mailer"
+	"github.com/ayehia0/org/pkg/token"
+	"github.com/ayehia0/org/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// the tests run the whole api on the memory driver, no external service is needed
+type testApp struct {
+	t      *testing.T
+	router *gin.Engine
+	appC   *types.AppC
+	mails  *mailer.MemoryMailer
+}
+
+// the tokens sent in the emails
+var tokenPattern = regexp.MustCompile(`v2\.local\.\S+`)
+
+func testConfig() *utils.AppConfig {
+	return &utils.AppConfig{
+		Env:                         "test",
+		JwtSecret:                   "0123456789abcdef0123456789abcdef",
+		TokenAccessExpiration:       time.Hour,
+		TokenRefreshExpiration:      24 * time.Hour,
+		InvitationExpiration:        24 * time.Hour,
+		TransferExpiration:          24 * time.Hour,
+		MFAChallengeExpiration:      5 * time.Minute,
+		MFAIssuer:                   "Organization API",
+		PasswordResetExpiration:     30 * time.Minute,
+		EmailVerificationExpiration: time.Hour,
+		RequireVerifiedMembership:   true,
+		Lockout:                     utils.LockoutConfig{MaxFailures: 5, Window: 15 * time.Minute, Duration: 15 * time.Minute},
+	}
+}
+
+func newTestApp(t *testing.T) *testApp {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	tokenCreator, err := token.NewPasteoToken(testConfig().JwtSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := memory.NewStore()
+	mails := mailer.NewMemoryMailer()
+	appC := &types.AppC{
+		DBStore:      store.DBStore(),
+		RDBStore:     store.RedisStore(),
+		TokenCreator: tokenCreator,
+		AppConfig:    testConfig(),
+		Mailer:       mails,
+		HealthChecks: map[string]database.HealthCheck{},
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	router, err := pkg.NewRouter(appC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testApp{t: t, router: router, appC: appC, mails: mails}
+}
+
+// send a request to the api, the body is encoded as json and the access token (if any) is sent as a bearer token
+func (a *testApp) do(method, path, accessToken string, body any) *httptest.ResponseRecorder {
+	a.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			a.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// send a request and decode its response, the status must be the expected one
+func call[T any](a *testApp, method, path, accessToken string, body any, status int) T {
+	a.t.Helper()
+
+	rec := a.do(method, path, accessToken, body)
+	if rec.Code != status {
+		a.t.Fatalf("%s %s: got %d, want %d: %s", method, path, rec.Code, status, rec.Body.String())
+	}
+
+	var resp T
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		a.t.Fatalf("%s %s: failed to decode the response: %v", method, path, err)
+	}
+	return resp
+}
+
+// the problem answered for a failed request, the status and the code must be the expected ones
+func expectProblem(a *testApp, rec *httptest.ResponseRecorder, status int, code string) {
+	a.t.Helper()
+
+	var problem struct {
+		Status int    `json:"status"`
+		Code   string `json:"code"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		a.t.Fatalf("failed to decode the problem: %v: %s", err, rec.Body.String())
+	}
+	if rec.Code != status || problem.Code != code {
+		a.t.Fatalf("got %d %q, want %d %q: %s", rec.Code, problem.Code, status, code, rec.Body.String())
+	}
+}
+
+// the token of the last email sent to the recipient
+func (a *testApp) mailedToken(to string) string {
+	a.t.Helper()
+
+	msg, ok := a.mails.Last(to)
+	if !ok {
+		a.t.Fatalf("no email sent to %s", to)
+	}
+	found := tokenPattern.FindString(msg.Body)
+	if found == "" {
+		a.t.Fatalf("no token in the email sent to %s", to)
+	}
+	return found
+}
+
+// create an account and verify its email
+func (a *testApp) signup(name, email, password string) {
+	a.t.Helper()
+
+	call[controllers.SignupResponse](a, http.MethodPost, "/signup", "", controllers.SignupRequest{Name: name, Email: email, Password: password}, http.StatusOK)
+	call[controllers.MessageResponse](a, http.MethodPost, "/verify-email", "", controllers.VerifyEmailRequest{Token: a.mailedToken(email)}, http.StatusOK)
+}
+
+// log in and return the tokens of the new session
+func (a *testApp) login(email, password string) controllers.RefreshTokenResponse {
+	a.t.Helper()
+	return call[controllers.RefreshTokenResponse](a, http.MethodPost, "/login", "", controllers.LoginRequest{Email: email, Password: password}, http.StatusOK)
+}
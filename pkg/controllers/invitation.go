@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	types "github.com/ayehia0/org/pkg/api"
+	api "github.com/ayehia0/org/pkg/api/middleware"
+	"github.com/ayehia0/org/pkg/apperror"
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/database/mongodb/repository"
+	"github.com/ayehia0/org/pkg/token"
+	"github.com/gin-gonic/gin"
+)
+
+// here we define all the controllers for the invitee side of the invitations
+type InvitationController interface {
+	ListInvitationsController(ctx *gin.Context)         // list the pending invitations of the authenticated user
+	AcceptInvitationController(ctx *gin.Context)        // accept an invitation by id
+	AcceptInvitationByTokenController(ctx *gin.Context) // accept an invitation using the signed invite token
+	DeclineInvitationController(ctx *gin.Context)       // decline an invitation by id
+}
+
+type appI struct {
+	types.AppC
+}
+
+func NewInvitationController(appC *types.AppC) InvitationController {
+	return &appI{AppC: *appC}
+}
+
+func (ai *appI) ListInvitationsController(ctx *gin.Context) {
+	user, ok := ai.authenticatedUser(ctx)
+	if !ok {
+		return
+	}
+
+	invitations, err := ai.DBStore.InvitationRepository.FindPendingByEmail(ctx, user.Email)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// the expired invitations are moved to their final state on the fly
+	pending := []models.Invitation{}
+	for _, invitation := range invitations {
+		if ai.expireInvitation(ctx, &invitation) {
+			continue
+		}
+		pending = append(pending, invitation)
+	}
+
+	ctx.JSON(http.StatusOK, pending)
+}
+
+func (ai *appI) AcceptInvitationController(ctx *gin.Context) {
+	user, ok := ai.authenticatedUser(ctx)
+	if !ok {
+		return
+	}
+
+	invitation, ok := ai.pendingInvitation(ctx, ctx.Param("id"), user)
+	if !ok {
+		return
+	}
+
+	ai.acceptInvitation(ctx, invitation, user)
+}
+
+func (ai *appI) AcceptInvitationByTokenController(ctx *gin.Context) {
+	var req AcceptInvitationByTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	// the subject of the invite token is the invitation id
+	payload, err := ai.TokenCreator.Verify(req.Token)
+	if err != nil || payload.Purpose != token.PurposeInvite {
+		ctx.Error(ErrInvalidInviteToken)
+		return
+	}
+
+	user, ok := ai.authenticatedUser(ctx)
+	if !ok {
+		return
+	}
+
+	invitation, ok := ai.pendingInvitation(ctx, payload.UserId, user)
+	if !ok {
+		return
+	}
+
+	ai.acceptInvitation(ctx, invitation, user)
+}
+
+func (ai *appI) DeclineInvitationController(ctx *gin.Context) {
+	user, ok := ai.authenticatedUser(ctx)
+	if !ok {
+		return
+	}
+
+	invitation, ok := ai.pendingInvitation(ctx, ctx.Param("id"), user)
+	if !ok {
+		return
+	}
+
+	err := ai.DBStore.InvitationRepository.UpdateStatus(ctx, invitation.ID, models.InvitationStatusDeclined)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "Invitation has been declined successfully"})
+}
+
+// helper function to get the user behind the access token
+func (ai *appI) authenticatedUser(ctx *gin.Context) (*models.User, bool) {
+	payload := ctx.MustGet(api.AuthPayloadKey).(*token.Payload)
+	user, err := ai.DBStore.UserRepository.FindByID(ctx, payload.UserId)
+	if err != nil {
+		ctx.Error(err)
+		return nil, false
+	}
+	return user, true
+}
+
+// helper function to load an invitation that belongs to the user and is still waiting for an answer
+func (ai *appI) pendingInvitation(ctx *gin.Context, id string, user *models.User) (*models.Invitation, bool) {
+	invitation, err := ai.DBStore.InvitationRepository.FindByID(ctx, id)
+	// the email of the invitation is written by the inviter, its case doesn't matter
+	if err != nil || !strings.EqualFold(invitation.Email, user.Email) {
+		ctx.Error(repository.ErrInvitationNotFound)
+		return nil, false
+	}
+
+	if invitation.Status != models.InvitationStatusPending {
+		ctx.Error(ErrInvitationClosed.WithMessage("invitation has already been " + invitation.Status))
+		return nil, false
+	}
+
+	if ai.expireInvitation(ctx, invitation) {
+		ctx.Error(ErrInvitationExpired)
+		return nil, false
+	}
+
+	return invitation, true
+}
+
+// helper function to mark the invitation as expired if it's too late to answer it, returns true if expired
+func (ai *appI) expireInvitation(ctx *gin.Context, invitation *models.Invitation) bool {
+	if time.Now().Before(invitation.ExpiresAt) {
+		return false
+	}
+	// best effort, the invitation is treated as expired anyway
+	_ = ai.DBStore.InvitationRepository.UpdateStatus(ctx, invitation.ID, models.InvitationStatusExpired)
+	invitation.Status = models.InvitationStatusExpired
+	return true
+}
+
+// helper function to add the user to the organization and close the invitation
+func (ai *appI) acceptInvitation(ctx *gin.Context, invitation *models.Invitation, user *models.User) {
+	if ai.AppConfig.RequireVerifiedMembership && !user.EmailVerified {
+		ctx.Error(ErrEmailNotVerified.WithMessage("email must be verified before joining an organization"))
+		return
+	}
+
+	isMember, err := ai.DBStore.OrganizationRepository.IsUserInOrganization(ctx, invitation.OrganizationID, user.Email)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if !isMember {
+		err = ai.DBStore.OrganizationRepository.AddMember(ctx, invitation.OrganizationID, &models.Member{
+			ID:          user.ID,
+			Name:        user.Name,
+			Email:       user.Email,
+			AccessLevel: invitation.AccessLevel,
+		})
+		// a concurrent accept might have added the user in the meantime
+		if err != nil && !errors.Is(err, repository.ErrMemberExists) {
+			ctx.Error(err)
+			return
+		}
+	}
+
+	err = ai.DBStore.InvitationRepository.UpdateStatus(ctx, invitation.ID, models.InvitationStatusAccepted)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, AcceptInvitationResponse{
+		Message:        "Invitation has been accepted successfully",
+		OrganizationID: invitation.OrganizationID,
+		AccessLevel:    invitation.AccessLevel,
+	})
+}
@@ -0,0 +1,14 @@
+package controllers
+
+// here we put all the request and response types for the invitation controller
+
+// Accepting an invitation using the token sent to the invitee
+type AcceptInvitationByTokenRequest struct {
+	Token string `json:"invite_token" binding:"required"`
+}
+
+type AcceptInvitationResponse struct {
+	Message        string `json:"message"`
+	OrganizationID string `json:"organization_id"`
+	AccessLevel    string `json:"access_level"`
+}
@@ -0,0 +1,105 @@
+package controllers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ayehia0/org/pkg/controllers"
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+)
+
+// an owner with an organization and the invitation of another user
+func invite(t *testing.T, app *testApp, inviteeEmail, accessLevel string) (string, controllers.InviteUserToOrganizationResponse) {
+	t.Helper()
+
+	app.signup("owner", "owner@example.com", "password")
+	owner := app.login("owner@example.com", "password")
+
+	org := call[controllers.OrganizationIDResponse](app, http.MethodPost, "/organizations/", owner.AccessToken,
+		controllers.CreateOrganizationRequest{Name: "acme", Desc: "the acme organization"}, http.StatusCreated)
+	invitation := call[controllers.InviteUserToOrganizationResponse](app, http.MethodPost, "/organizations/"+org.OrganizationID+"/invite", owner.AccessToken,
+		controllers.InviteUserToOrganizationRequest{Email: inviteeEmail, AccessLevel: accessLevel}, http.StatusCreated)
+	return org.OrganizationID, invitation
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	app := newTestApp(t)
+	orgID, invitation := invite(t, app, "bob@example.com", models.AccessLevelAdmin)
+
+	// the invitation waits for the account of the invitee
+	app.signup("bob", "bob@example.com", "password")
+	bob := app.login("bob@example.com", "password")
+
+	pending := call[[]models.Invitation](app, http.MethodGet, "/invitations/", bob.AccessToken, nil, http.StatusOK)
+	if len(pending) != 1 || pending[0].ID != invitation.InvitationID {
+		t.Fatalf("unexpected pending invitations: %+v", pending)
+	}
+
+	accepted := call[controllers.AcceptInvitationResponse](app, http.MethodPost, "/invitations/"+invitation.InvitationID+"/accept", bob.AccessToken, nil, http.StatusOK)
+	if accepted.OrganizationID != orgID || accepted.AccessLevel != models.AccessLevelAdmin {
+		t.Fatalf("unexpected accepted invitation: %+v", accepted)
+	}
+
+	org := call[controllers.OrganizationResponse](app, http.MethodGet, "/organizations/"+orgID, bob.AccessToken, nil, http.StatusOK)
+	if org.AccessLevel != models.AccessLevelAdmin {
+		t.Fatalf("got access level %q, want admin", org.AccessLevel)
+	}
+
+	// the invitation is closed once accepted
+	rec := app.do(http.MethodPost, "/invitations/"+invitation.InvitationID+"/accept", bob.AccessToken, nil)
+	expectProblem(app, rec, http.StatusConflict, "invitation_closed")
+	pending = call[[]models.Invitation](app, http.MethodGet, "/invitations/", bob.AccessToken, nil, http.StatusOK)
+	if len(pending) != 0 {
+		t.Fatalf("the accepted invitation is still pending: %+v", pending)
+	}
+}
+
+func TestAcceptInvitationByToken(t *testing.T) {
+	app := newTestApp(t)
+	orgID, invitation := invite(t, app, "bob@example.com", "")
+
+	app.signup("bob", "bob@example.com", "password")
+	bob := app.login("bob@example.com", "password")
+
+	accepted := call[controllers.AcceptInvitationResponse](app, http.MethodPost, "/invitations/accept", bob.AccessToken,
+		controllers.AcceptInvitationByTokenRequest{Token: invitation.InviteToken}, http.StatusOK)
+	if accepted.OrganizationID != orgID || accepted.AccessLevel != models.AccessLevelMember {
+		t.Fatalf("unexpected accepted invitation: %+v", accepted)
+	}
+}
+
+func TestAcceptInvitationIgnoresTheEmailCase(t *testing.T) {
+	app := newTestApp(t)
+	orgID, invitation := invite(t, app, "Bob@Example.com", "")
+
+	app.signup("bob", "bob@example.com", "password")
+	bob := app.login("bob@example.com", "password")
+
+	accepted := call[controllers.AcceptInvitationResponse](app, http.MethodPost, "/invitations/"+invitation.InvitationID+"/accept", bob.AccessToken, nil, http.StatusOK)
+	if accepted.OrganizationID != orgID {
+		t.Fatalf("unexpected accepted invitation: %+v", accepted)
+	}
+}
+
+func TestAcceptInvitationOfSomeoneElse(t *testing.T) {
+	app := newTestApp(t)
+	_, invitation := invite(t, app, "bob@example.com", "")
+
+	app.signup("eve", "eve@example.com", "password")
+	eve := app.login("eve@example.com", "password")
+
+	rec := app.do(http.MethodPost, "/invitations/"+invitation.InvitationID+"/accept", eve.AccessToken, nil)
+	expectProblem(app, rec, http.StatusNotFound, "invitation_not_found")
+}
+
+func TestAcceptInvitationRequiresVerifiedEmail(t *testing.T) {
+	app := newTestApp(t)
+	_, invitation := invite(t, app, "bob@example.com", "")
+
+	// the email of bob isn't verified
+	call[controllers.SignupResponse](app, http.MethodPost, "/signup", "", controllers.SignupRequest{Name: "bob", Email: "bob@example.com", Password: "password"}, http.StatusOK)
+	bob := app.login("bob@example.com", "password")
+
+	rec := app.do(http.MethodPost, "/invitations/"+invitation.InvitationID+"/accept", bob.AccessToken, nil)
+	expectProblem(app, rec, http.StatusForbidden, "email_not_verified")
+}
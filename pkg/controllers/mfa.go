@@ -0,0 +1,273 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	types "github.com/ayehia0/org/pkg/api"
+	api "github.com/ayehia0/org/pkg/api/middleware"
+	"github.com/ayehia0/org/pkg/apperror"
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/database/mongodb/repository"
+	"github.com/ayehia0/org/pkg/token"
+	"github.com/ayehia0/org/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// the number of recovery codes generated for the user
+const recoveryCodesCount = 10
+
+// here we define all the controllers to manage the two factor authentication of the authenticated user
+type MFAController interface {
+	EnrollMFAController(ctx *gin.Context)          // generate a new secret to be added to an authenticator app
+	ConfirmMFAController(ctx *gin.Context)         // confirm the secret with a code and enable the two factor authentication
+	DisableMFAController(ctx *gin.Context)         // disable the two factor authentication
+	ResetRecoveryCodesController(ctx *gin.Context) // replace the recovery codes by new ones
+}
+
+type appM struct {
+	types.AppC
+}
+
+func NewMFAController(appC *types.AppC) MFAController {
+	return &appM{AppC: *appC}
+}
+
+func (am *appM) EnrollMFAController(ctx *gin.Context) {
+	var req EnrollMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	user, ok := am.authenticatedUser(ctx)
+	if !ok {
+		return
+	}
+
+	if user.MFA.Enabled {
+		ctx.Error(ErrMFAAlreadyEnabled)
+		return
+	}
+
+	if err := utils.ComparePasswords(req.Password, user.Password); err != nil {
+		ctx.Error(ErrInvalidCredentials)
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		ctx.Error(apperror.Internal("failed to generate the secret", err))
+		return
+	}
+
+	// the secret isn't used until the user confirms it
+	err = am.DBStore.UserRepository.UpdateMFA(ctx, user.ID, &models.MFA{Secret: secret})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, EnrollMFAResponse{
+		Secret:  secret,
+		URI:     utils.TOTPURI(am.AppConfig.MFAIssuer, user.Email, secret),
+		Message: "Add the secret to your authenticator app and confirm it with a code",
+	})
+}
+
+func (am *appM) ConfirmMFAController(ctx *gin.Context) {
+	var req MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	user, ok := am.authenticatedUser(ctx)
+	if !ok {
+		return
+	}
+
+	if user.MFA.Enabled {
+		ctx.Error(ErrMFAAlreadyEnabled)
+		return
+	}
+
+	if user.MFA.Secret == "" {
+		ctx.Error(ErrMFANotEnrolled)
+		return
+	}
+
+	if ok, err := useTOTP(ctx, &am.AppC, user, req.Code); !ok {
+		ctx.Error(invalidCode(err))
+		return
+	}
+
+	codes, hashed, err := newRecoveryCodes()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	err = am.DBStore.UserRepository.UpdateMFA(ctx, user.ID, &models.MFA{
+		Enabled:       true,
+		Secret:        user.MFA.Secret,
+		RecoveryCodes: hashed,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, RecoveryCodesResponse{
+		RecoveryCodes: codes,
+		Message:       "Two factor authentication has been enabled successfully, keep the recovery codes somewhere safe",
+	})
+}
+
+func (am *appM) DisableMFAController(ctx *gin.Context) {
+	var req DisableMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	user, ok := am.authenticatedUser(ctx)
+	if !ok {
+		return
+	}
+
+	if !user.MFA.Enabled {
+		ctx.Error(ErrMFANotEnabled)
+		return
+	}
+
+	if err := utils.ComparePasswords(req.Password, user.Password); err != nil {
+		ctx.Error(ErrInvalidCredentials)
+		return
+	}
+
+	if ok, err := useMFA(ctx, &am.AppC, user, req.Code, req.RecoveryCode); !ok {
+		ctx.Error(invalidCode(err))
+		return
+	}
+
+	err := am.DBStore.UserRepository.UpdateMFA(ctx, user.ID, &models.MFA{})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "Two factor authentication has been disabled successfully"})
+}
+
+func (am *appM) ResetRecoveryCodesController(ctx *gin.Context) {
+	var req MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	user, ok := am.authenticatedUser(ctx)
+	if !ok {
+		return
+	}
+
+	if !user.MFA.Enabled {
+		ctx.Error(ErrMFANotEnabled)
+		return
+	}
+
+	if ok, err := useTOTP(ctx, &am.AppC, user, req.Code); !ok {
+		ctx.Error(invalidCode(err))
+		return
+	}
+
+	codes, hashed, err := newRecoveryCodes()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	user.MFA.RecoveryCodes = hashed
+	if err := am.DBStore.UserRepository.UpdateMFA(ctx, user.ID, &user.MFA); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, RecoveryCodesResponse{
+		RecoveryCodes: codes,
+		Message:       "Recovery codes have been reset successfully, the old ones don't work anymore",
+	})
+}
+
+// helper function to get the user behind the access token
+func (am *appM) authenticatedUser(ctx *gin.Context) (*models.User, bool) {
+	payload := ctx.MustGet(api.AuthPayloadKey).(*token.Payload)
+	user, err := am.DBStore.UserRepository.FindByID(ctx, payload.UserId)
+	if err != nil {
+		ctx.Error(err)
+		return nil, false
+	}
+	return user, true
+}
+
+// helper function to check the second factor of the user, either a code from the authenticator app or a recovery code
+// the check and the use happen in the repository so a code (or a recovery code) can't be accepted twice, even concurrently
+// ok is false for a wrong or used code, err is only set when the repository fails
+func useMFA(ctx context.Context, appC *types.AppC, user *models.User, code, recoveryCode string) (bool, error) {
+	if code != "" {
+		return useTOTP(ctx, appC, user, code)
+	}
+
+	for _, hashed := range user.MFA.RecoveryCodes {
+		if utils.ComparePasswords(recoveryCode, hashed) != nil {
+			continue
+		}
+		return usedCode(appC.DBStore.UserRepository.UseRecoveryCode(ctx, user.ID, hashed))
+	}
+	return false, nil
+}
+
+// helper function to check a code from the authenticator app, its time step has to be later than the last accepted one
+func useTOTP(ctx context.Context, appC *types.AppC, user *models.User, code string) (bool, error) {
+	step, ok := utils.MatchTOTP(code, user.MFA.Secret, time.Now())
+	if !ok {
+		return false, nil
+	}
+	return usedCode(appC.DBStore.UserRepository.UseTOTPStep(ctx, user.ID, step))
+}
+
+// a code used by someone else in the meantime is a wrong code
+func usedCode(err error) (bool, error) {
+	if errors.Is(err, repository.ErrCodeUsed) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// the error answered for a refused code
+func invalidCode(err error) error {
+	if err != nil {
+		return err
+	}
+	return ErrInvalidCode
+}
+
+// helper function to generate the recovery codes, returns the plain codes (shown once) and the hashes (saved)
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodesCount)
+	if err != nil {
+		return nil, nil, apperror.Internal("failed to generate the recovery codes", err)
+	}
+
+	hashed := make([]string, len(codes))
+	for i, code := range codes {
+		hashed[i], err = utils.GenerateHash(code)
+		if err != nil {
+			return nil, nil, apperror.Internal("failed to hash the recovery codes", err)
+		}
+	}
+	return codes, hashed, nil
+}
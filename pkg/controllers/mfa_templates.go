@@ -0,0 +1,45 @@
+package controllers
+
+// here we put all the request and response types for the two factor authentication
+
+// Starting the enrollment requires the password, a stolen access token alone can't set up the second factor
+type EnrollMFARequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// Confirming the enrollment or resetting the recovery codes with a code from the authenticator app
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// Disabling the two factor authentication requires the password and a code (or a recovery code)
+type DisableMFARequest struct {
+	Password     string `json:"password" binding:"required"`
+	Code         string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// The second step of the login
+type LoginMFARequest struct {
+	MFAToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// The response of the first login step when the second factor is required
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	Message     string `json:"message"`
+}
+
+type EnrollMFAResponse struct {
+	Secret  string `json:"secret"`
+	URI     string `json:"otpauth_uri"`
+	Message string `json:"message"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	Message       string   `json:"message"`
+}
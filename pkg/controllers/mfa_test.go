@@ -0,0 +1,94 @@
+package controllers_test
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ayehia0/org/pkg/controllers"
+	"github.com/ayehia0/org/pkg/utils"
+)
+
+// the code shown by the authenticator app at the given time
+func totp(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	code, err := utils.GenerateTOTP(secret, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// enable the two factor authentication of the user, returns the secret, the code that confirmed it and the recovery codes
+func enableMFA(t *testing.T, app *testApp, accessToken string) (string, string, []string) {
+	t.Helper()
+	enrollment := call[controllers.EnrollMFAResponse](app, http.MethodPost, "/me/mfa/enroll", accessToken, controllers.EnrollMFARequest{Password: "password"}, http.StatusOK)
+	code := totp(t, enrollment.Secret, time.Now())
+	codes := call[controllers.RecoveryCodesResponse](app, http.MethodPost, "/me/mfa/confirm", accessToken, controllers.MFACodeRequest{Code: code}, http.StatusOK)
+	return enrollment.Secret, code, codes.RecoveryCodes
+}
+
+// the first step of the login of a user with the two factor authentication
+func mfaToken(app *testApp, email, password string) string {
+	return call[controllers.MFAChallengeResponse](app, http.MethodPost, "/login", "", controllers.LoginRequest{Email: email, Password: password}, http.StatusOK).MFAToken
+}
+
+func TestEnrollMFARequiresThePassword(t *testing.T) {
+	app := newTestApp(t)
+	app.signup("alice", "alice@example.com", "password")
+	tokens := app.login("alice@example.com", "password")
+
+	rec := app.do(http.MethodPost, "/me/mfa/enroll", tokens.AccessToken, controllers.EnrollMFARequest{Password: "wrong"})
+	expectProblem(app, rec, http.StatusUnauthorized, "invalid_credentials")
+	rec = app.do(http.MethodPost, "/me/mfa/confirm", tokens.AccessToken, controllers.MFACodeRequest{Code: "123456"})
+	expectProblem(app, rec, http.StatusBadRequest, "mfa_not_enrolled")
+}
+
+func TestLoginMFACodeIsSingleUse(t *testing.T) {
+	app := newTestApp(t)
+	app.signup("alice", "alice@example.com", "password")
+	secret, confirmed, _ := enableMFA(t, app, app.login("alice@example.com", "password").AccessToken)
+
+	// the code used to confirm the enrollment can't be used again to log in
+	rec := app.do(http.MethodPost, "/login/mfa", "", controllers.LoginMFARequest{MFAToken: mfaToken(app, "alice@example.com", "password"), Code: confirmed})
+	expectProblem(app, rec, http.StatusUnauthorized, "invalid_code")
+
+	// the code of the next period is accepted once (the codes are valid for a few periods)
+	next := totp(t, secret, time.Now().Add(30*time.Second))
+	call[controllers.RefreshTokenResponse](app, http.MethodPost, "/login/mfa", "", controllers.LoginMFARequest{MFAToken: mfaToken(app, "alice@example.com", "password"), Code: next}, http.StatusOK)
+	rec = app.do(http.MethodPost, "/login/mfa", "", controllers.LoginMFARequest{MFAToken: mfaToken(app, "alice@example.com", "password"), Code: next})
+	expectProblem(app, rec, http.StatusUnauthorized, "invalid_code")
+}
+
+func TestLoginRecoveryCodeIsSingleUse(t *testing.T) {
+	app := newTestApp(t)
+	app.signup("alice", "alice@example.com", "password")
+	_, _, recoveryCodes := enableMFA(t, app, app.login("alice@example.com", "password").AccessToken)
+
+	// the same recovery code is sent by concurrent logins, only one of them gets in
+	var wg sync.WaitGroup
+	codes := make([]int, 5)
+	for i := range codes {
+		token := mfaToken(app, "alice@example.com", "password")
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = app.do(http.MethodPost, "/login/mfa", "", controllers.LoginMFARequest{MFAToken: token, RecoveryCode: recoveryCodes[0]}).Code
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, code := range codes {
+		if code == http.StatusOK {
+			succeeded++
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d logins succeeded with the same recovery code, want 1: %v", succeeded, codes)
+	}
+
+	// the other recovery codes still work
+	call[controllers.RefreshTokenResponse](app, http.MethodPost, "/login/mfa", "", controllers.LoginMFARequest{MFAToken: mfaToken(app, "alice@example.com", "password"), RecoveryCode: recoveryCodes[1]}, http.StatusOK)
+}
@@ -1,13 +1,16 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	types "github.com/ayehia0/org/pkg/api"
 	api "github.com/ayehia0/org/pkg/api/middleware"
+	"github.com/ayehia0/org/pkg/apperror"
 	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/database/mongodb/repository"
 	"github.com/ayehia0/org/pkg/token"
-	"github.com/ayehia0/org/pkg/utils"
 	"github.com/gin-gonic/gin"
 )
 
@@ -15,9 +18,15 @@ type OrgController interface {
 	CreateOrganizationController(ctx *gin.Context)       // create a new organization
 	DeleteOrganizationController(ctx *gin.Context)       // delete an organization
 	UpdateOrganizationController(ctx *gin.Context)       // update an organization
-	GetAllOrganizationsController(ctx *gin.Context)      // get all organizations
+	GetAllOrganizationsController(ctx *gin.Context)      // get the organizations of the user page by page
 	GetOrganizationByIDController(ctx *gin.Context)      // get an organization by id
 	InviteUserToOrganizationController(ctx *gin.Context) // invite a user to an organization
+	RemoveMemberController(ctx *gin.Context)             // remove a member from an organization
+	LeaveOrganizationController(ctx *gin.Context)        // leave an organization
+	UpdateMemberAccessLevelController(ctx *gin.Context)  // change the access level of a member
+	TransferOwnershipController(ctx *gin.Context)        // propose the ownership to another member
+	AcceptTransferController(ctx *gin.Context)           // accept the proposed ownership
+	DeclineTransferController(ctx *gin.Context)          // decline (target) or cancel (owner) the proposed ownership
 }
 
 type appO struct {
@@ -31,54 +40,57 @@ func NewOrgController(appC *types.AppC) OrgController {
 func (ao *appO) CreateOrganizationController(ctx *gin.Context) {
 	var req CreateOrganizationRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, utils.ErrorResp(err))
+		ctx.Error(apperror.InvalidRequest(err))
 		return
 	}
 
 	payload := ctx.MustGet(api.AuthPayloadKey).(*token.Payload)
+	user, err := ao.DBStore.UserRepository.FindByID(ctx, payload.UserId)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// the creator is the first owner of the organization
 	id, err := ao.DBStore.OrganizationRepository.Create(ctx, &models.Organization{
-		Name:    req.Name,
-		Desc:    req.Desc,
-		Members: []models.Member{},
+		Name: req.Name,
+		Desc: req.Desc,
+		Members: []models.Member{
+			{
+				ID:          user.ID,
+				Name:        user.Name,
+				Email:       user.Email,
+				AccessLevel: models.AccessLevelOwner,
+			},
+		},
 		Creator: payload.UserId,
 	})
 
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, utils.ErrorResp(err))
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, gin.H{
-		"message":         "Organization has been created successfully",
-		"organization_id": id,
+	ctx.JSON(http.StatusCreated, OrganizationIDResponse{
+		Message:        "Organization has been created successfully",
+		OrganizationID: id,
 	})
 }
 
 func (ao *appO) DeleteOrganizationController(ctx *gin.Context) {
-	id := ctx.Param("id")
-	org, err := ao.DBStore.OrganizationRepository.FindByID(ctx, id)
-	if err != nil {
-		ctx.JSON(http.StatusNotFound, utils.ErrorResp(err))
+	// only the owners can delete the organization
+	org, _, ok := ao.authorizeOrganization(ctx, permDeleteOrganization)
+	if !ok {
 		return
 	}
 
-	payload := ctx.MustGet(api.AuthPayloadKey).(*token.Payload)
-
-	// check if the authenticated user is the creator of the organization
-	if org.Creator != payload.UserId {
-		ctx.JSON(http.StatusUnauthorized, utils.ErrorResp(err))
-		return
-	}
-
-	err = ao.DBStore.OrganizationRepository.Delete(ctx, id)
+	err := ao.DBStore.OrganizationRepository.Delete(ctx, org.ID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, utils.ErrorResp(err))
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"message": "Organization has been deleted successfully",
-	})
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "Organization has been deleted successfully"})
 }
 
 func (ao *appO) UpdateOrganizationController(ctx *gin.Context) {
@@ -86,105 +98,429 @@ func (ao *appO) UpdateOrganizationController(ctx *gin.Context) {
 	var req UpdateOrganizationRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, utils.ErrorResp(err))
+		ctx.Error(apperror.InvalidRequest(err))
 		return
 	}
 
-	// get the organization by id
-	id := ctx.Param("id")
+	// get the organization by id and make sure the user can edit it
+	org, _, ok := ao.authorizeOrganization(ctx, permUpdateOrganization)
+	if !ok {
+		return
+	}
 
 	org, err := ao.DBStore.OrganizationRepository.Update(ctx, &models.Organization{
-		ID:   id,
+		ID:   org.ID,
 		Name: req.Name,
 		Desc: req.Desc,
 	})
 
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, utils.ErrorResp(err))
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"organization_id": org.ID,
-		"name":            org.Name,
-		"description":     org.Desc,
+	ctx.JSON(http.StatusOK, UpdateOrganizationResponse{
+		OrganizationID: org.ID,
+		Name:           org.Name,
+		Description:    org.Desc,
 	})
 }
 
-// TODO: don't return the the creator nor id
+// the default number of organizations per page
+const defaultOrganizationsLimit = 20
+
 func (ao *appO) GetAllOrganizationsController(ctx *gin.Context) {
-	orgs, err := ao.DBStore.OrganizationRepository.FindAll(ctx)
+	var req ListOrganizationsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	if req.Limit == 0 {
+		req.Limit = defaultOrganizationsLimit
+	}
+
+	// only the organizations the user created or is a member of
+	payload := ctx.MustGet(api.AuthPayloadKey).(*token.Payload)
+	orgs, next, err := ao.DBStore.OrganizationRepository.ListByMember(ctx, repository.OrganizationListOptions{
+		MemberID:   payload.UserId,
+		Name:       req.Name,
+		SortBy:     req.Sort,
+		Descending: req.Order == "desc",
+		Cursor:     req.Cursor,
+		Limit:      req.Limit,
+	})
 
+	// an invalid cursor is answered with 400 by the error middleware
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, utils.ErrorResp(err))
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, orgs)
+	resp := ListOrganizationsResponse{
+		Organizations: []OrganizationSummary{},
+		NextCursor:    next,
+	}
+	for i := range orgs {
+		resp.Organizations = append(resp.Organizations, OrganizationSummary{
+			ID:          orgs[i].ID,
+			Name:        orgs[i].Name,
+			Description: orgs[i].Desc,
+			AccessLevel: memberAccessLevel(&orgs[i], payload.UserId),
+		})
+	}
+
+	ctx.JSON(http.StatusOK, resp)
 }
 
 func (ao *appO) GetOrganizationByIDController(ctx *gin.Context) {
-	id := ctx.Param("id")
+	org, accessLevel, ok := ao.authorizeOrganization(ctx, permViewOrganization)
+	if !ok {
+		return
+	}
 
-	org, err := ao.DBStore.OrganizationRepository.FindByID(ctx, id)
+	resp := OrganizationResponse{
+		OrganizationID: org.ID,
+		Name:           org.Name,
+		Description:    org.Desc,
+		AccessLevel:    accessLevel,
+	}
 
-	if err != nil {
-		ctx.JSON(http.StatusNotFound, utils.ErrorResp(err))
-		return
+	// viewers can't see the other members
+	if hasPermission(accessLevel, permViewMembers) {
+		resp.Members = org.Members
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"organization_id": org.ID,
-		"name":            org.Name,
-		"description":     org.Desc,
-		"members":         org.Members,
-	})
+	// the pending transfer is only visible to the people involved
+	payload := ctx.MustGet(api.AuthPayloadKey).(*token.Payload)
+	if org.PendingTransfer != nil && (accessLevel == models.AccessLevelOwner || org.PendingTransfer.To == payload.UserId) {
+		resp.PendingTransfer = org.PendingTransfer
+	}
+
+	ctx.JSON(http.StatusOK, resp)
 }
 
 func (ao *appO) InviteUserToOrganizationController(ctx *gin.Context) {
 	var req InviteUserToOrganizationRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, utils.ErrorResp(err))
+		ctx.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	org, inviterAccessLevel, ok := ao.authorizeOrganization(ctx, permInviteMembers)
+	if !ok {
 		return
 	}
 
-	id := ctx.Param("id")
+	// the default access level for the invited users
+	accessLevel := req.AccessLevel
+	if accessLevel == "" {
+		accessLevel = models.AccessLevelMember
+	}
 
-	org, err := ao.DBStore.UserRepository.FindByEmail(ctx, req.Email)
-	if err != nil {
-		ctx.JSON(http.StatusNotFound, utils.ErrorResp(err))
+	if !canGrantAccessLevel(inviterAccessLevel, accessLevel) {
+		ctx.Error(ErrCannotGrantAccessLevel)
 		return
 	}
 
+	// people without an account can be invited too, the invitation is linked to them once they signup
+	userID := ""
+	user, err := ao.DBStore.UserRepository.FindByEmail(ctx, req.Email)
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		ctx.Error(err)
+		return
+	}
+	if user != nil {
+		userID = user.ID
+	}
+
 	// check if the user is already a member of the organization
-	isMember, err := ao.DBStore.OrganizationRepository.IsUserInOrganization(ctx, id, req.Email)
+	isMember, err := ao.DBStore.OrganizationRepository.IsUserInOrganization(ctx, org.ID, req.Email)
 
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, utils.ErrorResp(err))
+		ctx.Error(err)
 		return
 	}
 
 	if isMember {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"message": "User is already a member of the organization",
-		})
+		ctx.Error(ErrAlreadyMember)
+		return
+	}
+
+	// don't spam the user with the same invitation
+	hasPending, err := ao.DBStore.InvitationRepository.HasPending(ctx, org.ID, req.Email)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if hasPending {
+		ctx.Error(ErrInvitationPending)
+		return
+	}
+
+	payload := ctx.MustGet(api.AuthPayloadKey).(*token.Payload)
+	now := time.Now()
+	invitation := &models.Invitation{
+		OrganizationID:   org.ID,
+		OrganizationName: org.Name,
+		Email:            req.Email,
+		UserID:           userID,
+		AccessLevel:      accessLevel,
+		InvitedBy:        payload.UserId,
+		Status:           models.InvitationStatusPending,
+		ExpiresAt:        now.Add(ao.AppConfig.InvitationExpiration),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+
+	invitationID, err := ao.DBStore.InvitationRepository.Create(ctx, invitation)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// the signed token allows the invitee to accept the invitation through a link
+	inviteToken, _, err := ao.TokenCreator.CreateWithPurpose(ctx, invitationID, token.PurposeInvite, ao.AppConfig.InvitationExpiration)
+	if err != nil {
+		ctx.Error(apperror.Internal("failed to create a token", err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, InviteUserToOrganizationResponse{
+		Message:      "User has been invited to the organization successfully",
+		InvitationID: invitationID,
+		InviteToken:  inviteToken,
+		ExpiresAt:    invitation.ExpiresAt,
+	})
+}
+
+func (ao *appO) RemoveMemberController(ctx *gin.Context) {
+	org, accessLevel, ok := ao.authorizeOrganization(ctx, permManageMembers)
+	if !ok {
+		return
+	}
+
+	memberID := ctx.Param("member_id")
+	memberAccess := memberAccessLevel(org, memberID)
+	if memberAccess == "" {
+		ctx.Error(repository.ErrMemberNotFound)
+		return
+	}
+
+	if !canManageMember(accessLevel, memberAccess) {
+		ctx.Error(ErrCannotRemoveMember)
+		return
+	}
+
+	if !ao.removeMember(ctx, org, memberID) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "Member has been removed from the organization successfully"})
+}
+
+func (ao *appO) LeaveOrganizationController(ctx *gin.Context) {
+	org, _, ok := ao.authorizeOrganization(ctx, permViewOrganization)
+	if !ok {
+		return
+	}
+
+	payload := ctx.MustGet(api.AuthPayloadKey).(*token.Payload)
+	if !ao.removeMember(ctx, org, payload.UserId) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "You have left the organization successfully"})
+}
+
+func (ao *appO) UpdateMemberAccessLevelController(ctx *gin.Context) {
+	var req UpdateMemberAccessLevelRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.InvalidRequest(err))
 		return
 	}
 
-	member := models.Member{
-		ID:          org.ID,
-		Name:        org.Name,
-		Email:       org.Email,
-		AccessLevel: "member", // you can change the access level
+	org, accessLevel, ok := ao.authorizeOrganization(ctx, permManageMembers)
+	if !ok {
+		return
+	}
+
+	memberID := ctx.Param("member_id")
+	member := findMember(org, memberID)
+	if member == nil {
+		ctx.Error(repository.ErrMemberNotFound)
+		return
+	}
+
+	// the owners can hand out any access level including the ownership
+	if !canManageMember(accessLevel, member.AccessLevel) ||
+		(accessLevel != models.AccessLevelOwner && !canGrantAccessLevel(accessLevel, req.AccessLevel)) {
+		ctx.Error(ErrCannotChangeAccessLevel)
+		return
+	}
+
+	// the organization can't be left without an owner
+	if member.AccessLevel == models.AccessLevelOwner && req.AccessLevel != models.AccessLevelOwner && countOwners(org) <= 1 {
+		ctx.Error(ErrLastOwner.WithMessage("the last owner of the organization can't be demoted"))
+		return
 	}
 
-	err = ao.DBStore.OrganizationRepository.InviteUserToOrganization(ctx, id, member)
+	err := ao.DBStore.OrganizationRepository.UpdateMemberAccessLevel(ctx, org.ID, memberID, req.AccessLevel)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, utils.ErrorResp(err))
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"message": "User has been invited to the organization successfully",
+	// a demoted creator isn't the owner anymore, the ownership goes to another owner
+	if org.Creator == memberID && req.AccessLevel != models.AccessLevelOwner {
+		member.AccessLevel = req.AccessLevel
+		if err := handOverOrganization(ctx, &ao.AppC, org, memberID); err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+
+	// a demoted owner can't hand over the organization anymore
+	if transfer := org.PendingTransfer; transfer != nil && transfer.From == memberID && req.AccessLevel != models.AccessLevelOwner {
+		if err := ao.DBStore.OrganizationRepository.ClearTransfer(ctx, org.ID); err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, UpdateMemberAccessLevelResponse{
+		Message:     "Member access level has been updated successfully",
+		MemberID:    memberID,
+		AccessLevel: req.AccessLevel,
 	})
 }
+
+// helper function to remove a user from the organization while keeping at least one owner
+// the response is written in case of failure and false is returned
+func (ao *appO) removeMember(ctx *gin.Context, org *models.Organization, userID string) bool {
+	if memberAccessLevel(org, userID) == models.AccessLevelOwner {
+		if countOwners(org) <= 1 {
+			ctx.Error(ErrLastOwner)
+			return false
+		}
+
+		// the creator is replaced by one of the remaining owners
+		if org.Creator == userID {
+			if err := handOverOrganization(ctx, &ao.AppC, org, userID); err != nil {
+				ctx.Error(err)
+				return false
+			}
+		}
+	}
+
+	// the creators of the old organizations don't have a member entry
+	if findMember(org, userID) != nil {
+		if err := ao.DBStore.OrganizationRepository.RemoveMember(ctx, org.ID, userID); err != nil {
+			ctx.Error(err)
+			return false
+		}
+	}
+
+	if err := clearTransferOf(ctx, &ao.AppC, org, userID); err != nil {
+		ctx.Error(err)
+		return false
+	}
+	return true
+}
+
+func (ao *appO) TransferOwnershipController(ctx *gin.Context) {
+	var req TransferOwnershipRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	org, _, ok := ao.authorizeOrganization(ctx, permTransferOwnership)
+	if !ok {
+		return
+	}
+
+	payload := ctx.MustGet(api.AuthPayloadKey).(*token.Payload)
+	if req.MemberID == payload.UserId || req.MemberID == org.Creator {
+		ctx.Error(ErrAlreadyOwner)
+		return
+	}
+
+	if findMember(org, req.MemberID) == nil {
+		ctx.Error(repository.ErrMemberNotFound)
+		return
+	}
+
+	now := time.Now()
+	transfer := &models.OwnershipTransfer{
+		From:      payload.UserId,
+		To:        req.MemberID,
+		ExpiresAt: now.Add(ao.AppConfig.TransferExpiration),
+		CreatedAt: now,
+	}
+
+	if err := ao.DBStore.OrganizationRepository.ProposeTransfer(ctx, org.ID, transfer); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, TransferOwnershipResponse{
+		Message:         "Ownership transfer has been proposed successfully",
+		PendingTransfer: transfer,
+	})
+}
+
+func (ao *appO) AcceptTransferController(ctx *gin.Context) {
+	org, _, ok := ao.authorizeOrganization(ctx, permViewOrganization)
+	if !ok {
+		return
+	}
+
+	payload := ctx.MustGet(api.AuthPayloadKey).(*token.Payload)
+	transfer := org.PendingTransfer
+	if transfer == nil || transfer.To != payload.UserId {
+		ctx.Error(repository.ErrTransferNotFound)
+		return
+	}
+
+	if time.Now().After(transfer.ExpiresAt) {
+		// best effort, the transfer is expired anyway
+		_ = ao.DBStore.OrganizationRepository.ClearTransfer(ctx, org.ID)
+		ctx.Error(ErrTransferExpired)
+		return
+	}
+
+	err := ao.DBStore.OrganizationRepository.CompleteTransfer(ctx, org.ID, transfer.From, transfer.To)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, OrganizationIDResponse{
+		Message:        "Ownership has been transferred successfully",
+		OrganizationID: org.ID,
+	})
+}
+
+func (ao *appO) DeclineTransferController(ctx *gin.Context) {
+	org, accessLevel, ok := ao.authorizeOrganization(ctx, permViewOrganization)
+	if !ok {
+		return
+	}
+
+	// the target can decline it and the owners can cancel it
+	payload := ctx.MustGet(api.AuthPayloadKey).(*token.Payload)
+	transfer := org.PendingTransfer
+	if transfer == nil || (transfer.To != payload.UserId && !hasPermission(accessLevel, permTransferOwnership)) {
+		ctx.Error(repository.ErrTransferNotFound)
+		return
+	}
+
+	if err := ao.DBStore.OrganizationRepository.ClearTransfer(ctx, org.ID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "Ownership transfer has been canceled successfully"})
+}
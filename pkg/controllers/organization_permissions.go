@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	api "github.com/ayehia0/org/pkg/api/middleware"
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/database/mongodb/repository"
+	"github.com/ayehia0/org/pkg/token"
+	"github.com/gin-gonic/gin"
+)
+
+// here we define the permission matrix for the organization routes
+
+type permission string
+
+const (
+	permViewOrganization   permission = "organization:view"     // see the name and description
+	permViewMembers        permission = "organization:members"  // see the members of the organization
+	permUpdateOrganization permission = "organization:update"   // change the name and description
+	permInviteMembers      permission = "organization:invite"   // invite new members
+	permManageMembers      permission = "organization:manage"   // remove members and change their access level
+	permTransferOwnership  permission = "organization:transfer" // hand the organization over to another member
+	permDeleteOrganization permission = "organization:delete"   // delete the whole organization
+)
+
+// the permissions each access level has
+var rolePermissions = map[string][]permission{
+	models.AccessLevelOwner: {
+		permViewOrganization, permViewMembers, permUpdateOrganization, permInviteMembers, permManageMembers,
+		permTransferOwnership, permDeleteOrganization,
+	},
+	models.AccessLevelAdmin: {
+		permViewOrganization, permViewMembers, permUpdateOrganization, permInviteMembers, permManageMembers,
+	},
+	models.AccessLevelMember: {
+		permViewOrganization, permViewMembers,
+	},
+	models.AccessLevelViewer: {
+		permViewOrganization,
+	},
+}
+
+// the higher the rank the more privileged the access level is
+var roleRank = map[string]int{
+	models.AccessLevelOwner:  4,
+	models.AccessLevelAdmin:  3,
+	models.AccessLevelMember: 2,
+	models.AccessLevelViewer: 1,
+}
+
+// returns the access level of the user inside the organization or an empty string if not a member
+// the creator is always treated as an owner
+func memberAccessLevel(org *models.Organization, userID string) string {
+	if org.Creator == userID {
+		return models.AccessLevelOwner
+	}
+	for _, member := range org.Members {
+		if member.ID == userID {
+			return member.AccessLevel
+		}
+	}
+	return ""
+}
+
+// check if the access level is allowed to do the given action
+func hasPermission(accessLevel string, perm permission) bool {
+	for _, p := range rolePermissions[accessLevel] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// a user can only hand out access levels lower or equal to his own, ownership can't be granted by an invite
+func canGrantAccessLevel(granter, accessLevel string) bool {
+	if accessLevel == models.AccessLevelOwner {
+		return false
+	}
+	return roleRank[granter] >= roleRank[accessLevel]
+}
+
+// the owners can manage everyone, the others can only manage the members with a lower access level
+func canManageMember(manager, member string) bool {
+	if manager == models.AccessLevelOwner {
+		return true
+	}
+	return roleRank[manager] > roleRank[member]
+}
+
+// returns the member entry of the user or nil if not found
+func findMember(org *models.Organization, userID string) *models.Member {
+	for i := range org.Members {
+		if org.Members[i].ID == userID {
+			return &org.Members[i]
+		}
+	}
+	return nil
+}
+
+// returns the number of owners of the organization, the creator is always counted
+func countOwners(org *models.Organization) int {
+	count := 0
+	for _, member := range org.Members {
+		if member.AccessLevel == models.AccessLevelOwner {
+			count++
+		}
+	}
+	if findMember(org, org.Creator) == nil {
+		count++
+	}
+	return count
+}
+
+// loads the organization given in the path and makes sure the authenticated user is allowed to do the action
+// the response is written in case of failure and false is returned
+func (ao *appO) authorizeOrganization(ctx *gin.Context, perm permission) (*models.Organization, string, bool) {
+	id := ctx.Param("id")
+	org, err := ao.DBStore.OrganizationRepository.FindByID(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return nil, "", false
+	}
+
+	payload := ctx.MustGet(api.AuthPayloadKey).(*token.Payload)
+	accessLevel := memberAccessLevel(org, payload.UserId)
+
+	// don't leak the existence of the organization to non members
+	if accessLevel == "" {
+		ctx.Error(repository.ErrOrganizationNotFound)
+		return nil, "", false
+	}
+
+	if !hasPermission(accessLevel, perm) {
+		ctx.Error(ErrPermissionDenied)
+		return nil, "", false
+	}
+
+	return org, accessLevel, true
+}
@@ -1,5 +1,11 @@
 package controllers
 
+import (
+	"time"
+
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+)
+
 // here we put all the request and response types for the organization controller
 
 // Creating a new organization request
@@ -16,5 +22,78 @@ type UpdateOrganizationRequest struct {
 
 // Invite a user to an organization request
 type InviteUserToOrganizationRequest struct {
-	Email string `json:"user_email" binding:"required,email"`
+	Email       string `json:"user_email" binding:"required,email"`
+	AccessLevel string `json:"access_level" binding:"omitempty,oneof=admin member viewer"`
+}
+
+// Changing the access level of a member request
+type UpdateMemberAccessLevelRequest struct {
+	AccessLevel string `json:"access_level" binding:"required,oneof=owner admin member viewer"`
+}
+
+// Proposing an ownership transfer to a member request
+type TransferOwnershipRequest struct {
+	MemberID string `json:"member_id" binding:"required"`
+}
+
+// Listing the organizations of the authenticated user request (query parameters)
+type ListOrganizationsRequest struct {
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor string `form:"cursor"`
+	Name   string `form:"name"`
+	Sort   string `form:"sort" binding:"omitempty,oneof=name created"`
+	Order  string `form:"order" binding:"omitempty,oneof=asc desc"`
+}
+
+// an organization in a list, the internal fields (creator, members, ...) aren't exposed
+type OrganizationSummary struct {
+	ID          string `json:"organization_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	AccessLevel string `json:"access_level"`
+}
+
+type ListOrganizationsResponse struct {
+	Organizations []OrganizationSummary `json:"organizations"`
+	NextCursor    string                `json:"next_cursor,omitempty"`
+}
+
+// the response of the actions on an organization (creating it, accepting its ownership)
+type OrganizationIDResponse struct {
+	Message        string `json:"message"`
+	OrganizationID string `json:"organization_id"`
+}
+
+type UpdateOrganizationResponse struct {
+	OrganizationID string `json:"organization_id"`
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+}
+
+// an organization as seen by one of its members, the members and the pending transfer are only shown to the ones allowed to see them
+type OrganizationResponse struct {
+	OrganizationID  string                    `json:"organization_id"`
+	Name            string                    `json:"name"`
+	Description     string                    `json:"description"`
+	AccessLevel     string                    `json:"access_level"`
+	Members         []models.Member           `json:"members,omitempty"`
+	PendingTransfer *models.OwnershipTransfer `json:"pending_transfer,omitempty"`
+}
+
+type InviteUserToOrganizationResponse struct {
+	Message      string    `json:"message"`
+	InvitationID string    `json:"invitation_id"`
+	InviteToken  string    `json:"invite_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type UpdateMemberAccessLevelResponse struct {
+	Message     string `json:"message"`
+	MemberID    string `json:"member_id"`
+	AccessLevel string `json:"access_level"`
+}
+
+type TransferOwnershipResponse struct {
+	Message         string                    `json:"message"`
+	PendingTransfer *models.OwnershipTransfer `json:"pending_transfer"`
 }
@@ -0,0 +1,141 @@
+package controllers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ayehia0/org/pkg/controllers"
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+)
+
+// an owner with a brand new organization
+func newOrganization(t *testing.T, app *testApp) (string, controllers.RefreshTokenResponse) {
+	t.Helper()
+
+	app.signup("owner", "owner@example.com", "password")
+	owner := app.login("owner@example.com", "password")
+
+	org := call[controllers.OrganizationIDResponse](app, http.MethodPost, "/organizations/", owner.AccessToken,
+		controllers.CreateOrganizationRequest{Name: "acme", Desc: "the acme organization"}, http.StatusCreated)
+	return org.OrganizationID, owner
+}
+
+// a new user invited by the owner who accepts the invitation, returns the tokens and the id of the member
+func join(t *testing.T, app *testApp, orgID, ownerToken, name, accessLevel string) (controllers.RefreshTokenResponse, string) {
+	t.Helper()
+
+	email := name + "@example.com"
+	invitation := call[controllers.InviteUserToOrganizationResponse](app, http.MethodPost, "/organizations/"+orgID+"/invite", ownerToken,
+		controllers.InviteUserToOrganizationRequest{Email: email, AccessLevel: accessLevel}, http.StatusCreated)
+
+	app.signup(name, email, "password")
+	tokens := app.login(email, "password")
+	call[controllers.AcceptInvitationResponse](app, http.MethodPost, "/invitations/"+invitation.InvitationID+"/accept", tokens.AccessToken, nil, http.StatusOK)
+
+	profile := call[controllers.ProfileResponse](app, http.MethodGet, "/me", tokens.AccessToken, nil, http.StatusOK)
+	return tokens, profile.ID
+}
+
+// change the access level of a member
+func setAccessLevel(app *testApp, orgID, managerToken, memberID, accessLevel string) {
+	call[controllers.UpdateMemberAccessLevelResponse](app, http.MethodPut, "/organizations/"+orgID+"/members/"+memberID, managerToken,
+		controllers.UpdateMemberAccessLevelRequest{AccessLevel: accessLevel}, http.StatusOK)
+}
+
+func TestMemberCannotManageTheOrganization(t *testing.T) {
+	app := newTestApp(t)
+	orgID, owner := newOrganization(t, app)
+	bob, _ := join(t, app, orgID, owner.AccessToken, "bob", models.AccessLevelMember)
+	_, carolID := join(t, app, orgID, owner.AccessToken, "carol", models.AccessLevelViewer)
+
+	// the members can see the organization but not change it
+	call[controllers.OrganizationResponse](app, http.MethodGet, "/organizations/"+orgID, bob.AccessToken, nil, http.StatusOK)
+
+	rec := app.do(http.MethodPut, "/organizations/"+orgID, bob.AccessToken, controllers.UpdateOrganizationRequest{Name: "evil"})
+	expectProblem(app, rec, http.StatusForbidden, "permission_denied")
+	rec = app.do(http.MethodPost, "/organizations/"+orgID+"/invite", bob.AccessToken,
+		controllers.InviteUserToOrganizationRequest{Email: "eve@example.com"})
+	expectProblem(app, rec, http.StatusForbidden, "permission_denied")
+	rec = app.do(http.MethodDelete, "/organizations/"+orgID+"/members/"+carolID, bob.AccessToken, nil)
+	expectProblem(app, rec, http.StatusForbidden, "permission_denied")
+	rec = app.do(http.MethodDelete, "/organizations/"+orgID, bob.AccessToken, nil)
+	expectProblem(app, rec, http.StatusForbidden, "permission_denied")
+}
+
+func TestAdminCannotRemoveAnOwner(t *testing.T) {
+	app := newTestApp(t)
+	orgID, owner := newOrganization(t, app)
+	bob, _ := join(t, app, orgID, owner.AccessToken, "bob", models.AccessLevelAdmin)
+	_, carolID := join(t, app, orgID, owner.AccessToken, "carol", models.AccessLevelAdmin)
+	_, daveID := join(t, app, orgID, owner.AccessToken, "dave", models.AccessLevelMember)
+	setAccessLevel(app, orgID, owner.AccessToken, carolID, models.AccessLevelOwner)
+
+	rec := app.do(http.MethodDelete, "/organizations/"+orgID+"/members/"+carolID, bob.AccessToken, nil)
+	expectProblem(app, rec, http.StatusForbidden, "cannot_remove_member")
+
+	// the admins can still remove the members below them
+	call[controllers.MessageResponse](app, http.MethodDelete, "/organizations/"+orgID+"/members/"+daveID, bob.AccessToken, nil, http.StatusOK)
+}
+
+func TestLastOwnerLeaving(t *testing.T) {
+	app := newTestApp(t)
+	orgID, owner := newOrganization(t, app)
+	bob, bobID := join(t, app, orgID, owner.AccessToken, "bob", models.AccessLevelAdmin)
+
+	rec := app.do(http.MethodPost, "/organizations/"+orgID+"/leave", owner.AccessToken, nil)
+	expectProblem(app, rec, http.StatusConflict, "last_owner")
+
+	// once there is another owner, the organization is handed over to it
+	setAccessLevel(app, orgID, owner.AccessToken, bobID, models.AccessLevelOwner)
+	call[controllers.MessageResponse](app, http.MethodPost, "/organizations/"+orgID+"/leave", owner.AccessToken, nil, http.StatusOK)
+
+	org := call[controllers.OrganizationResponse](app, http.MethodGet, "/organizations/"+orgID, bob.AccessToken, nil, http.StatusOK)
+	if org.AccessLevel != models.AccessLevelOwner {
+		t.Fatalf("got access level %q, want owner", org.AccessLevel)
+	}
+	rec = app.do(http.MethodGet, "/organizations/"+orgID, owner.AccessToken, nil)
+	expectProblem(app, rec, http.StatusNotFound, "organization_not_found")
+}
+
+func TestOwnershipTransfer(t *testing.T) {
+	app := newTestApp(t)
+	orgID, owner := newOrganization(t, app)
+	bob, bobID := join(t, app, orgID, owner.AccessToken, "bob", models.AccessLevelAdmin)
+
+	// only the target can accept the transfer
+	call[controllers.TransferOwnershipResponse](app, http.MethodPost, "/organizations/"+orgID+"/transfer", owner.AccessToken,
+		controllers.TransferOwnershipRequest{MemberID: bobID}, http.StatusCreated)
+	rec := app.do(http.MethodPost, "/organizations/"+orgID+"/transfer/accept", owner.AccessToken, nil)
+	expectProblem(app, rec, http.StatusNotFound, "transfer_not_found")
+	call[controllers.OrganizationIDResponse](app, http.MethodPost, "/organizations/"+orgID+"/transfer/accept", bob.AccessToken, nil, http.StatusOK)
+
+	org := call[controllers.OrganizationResponse](app, http.MethodGet, "/organizations/"+orgID, bob.AccessToken, nil, http.StatusOK)
+	if org.AccessLevel != models.AccessLevelOwner || org.PendingTransfer != nil {
+		t.Fatalf("unexpected organization after the transfer: %+v", org)
+	}
+	org = call[controllers.OrganizationResponse](app, http.MethodGet, "/organizations/"+orgID, owner.AccessToken, nil, http.StatusOK)
+	if org.AccessLevel != models.AccessLevelAdmin {
+		t.Fatalf("got access level %q for the previous owner, want admin", org.AccessLevel)
+	}
+}
+
+func TestTransferDroppedWhenTheProposerIsDemoted(t *testing.T) {
+	app := newTestApp(t)
+	orgID, owner := newOrganization(t, app)
+	bob, bobID := join(t, app, orgID, owner.AccessToken, "bob", models.AccessLevelAdmin)
+	carol, carolID := join(t, app, orgID, owner.AccessToken, "carol", models.AccessLevelAdmin)
+	setAccessLevel(app, orgID, owner.AccessToken, carolID, models.AccessLevelOwner)
+
+	call[controllers.TransferOwnershipResponse](app, http.MethodPost, "/organizations/"+orgID+"/transfer", carol.AccessToken,
+		controllers.TransferOwnershipRequest{MemberID: bobID}, http.StatusCreated)
+
+	// the proposer isn't an owner anymore, the proposal goes away with the ownership
+	setAccessLevel(app, orgID, owner.AccessToken, carolID, models.AccessLevelAdmin)
+
+	org := call[controllers.OrganizationResponse](app, http.MethodGet, "/organizations/"+orgID, owner.AccessToken, nil, http.StatusOK)
+	if org.PendingTransfer != nil {
+		t.Fatalf("the transfer of the demoted owner is still pending: %+v", org.PendingTransfer)
+	}
+	rec := app.do(http.MethodPost, "/organizations/"+orgID+"/transfer/accept", bob.AccessToken, nil)
+	expectProblem(app, rec, http.StatusNotFound, "transfer_not_found")
+}
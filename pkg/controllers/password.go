@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	types "github.com/ayehia0/org/pkg/api"
+	"github.com/ayehia0/org/pkg/apperror"
+	"github.com/ayehia0/org/pkg/database/mongodb/repository"
+	"github.com/ayehia0/org/pkg/mailer"
+	"github.com/ayehia0/org/pkg/token"
+	"github.com/ayehia0/org/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// here we define all the controllers to recover an account
+type PasswordController interface {
+	ForgotPasswordController(ctx *gin.Context) // send a reset token to the email of the user
+	ResetPasswordController(ctx *gin.Context)  // set a new password using the reset token
+}
+
+type appP struct {
+	types.AppC
+}
+
+func NewPasswordController(appC *types.AppC) PasswordController {
+	return &appP{AppC: *appC}
+}
+
+func (ap *appP) ForgotPasswordController(ctx *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	// the response is the same whether the user exists or not, so the emails can't be enumerated
+	resp := MessageResponse{Message: "If the email exists, a reset token has been sent to it"}
+
+	user, err := ap.DBStore.UserRepository.FindByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			ctx.JSON(http.StatusOK, resp)
+			return
+		}
+		ctx.Error(apperror.Internal("failed to get the user", err))
+		return
+	}
+
+	resetToken, _, err := ap.TokenCreator.CreateWithPurpose(ctx, user.ID, token.PurposeReset, ap.AppConfig.PasswordResetExpiration)
+	if err != nil {
+		ctx.Error(apperror.Internal("failed to create a token", err))
+		return
+	}
+
+	err = ap.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the following token to reset your password, it expires in %s:\n\n%s\n\nIf you didn't ask for it, ignore this email.\n",
+			user.Name, ap.AppConfig.PasswordResetExpiration, resetToken),
+	})
+	// a failure would tell that the email exists, the user can ask again
+	if err != nil {
+		ap.Logger.ErrorContext(ctx, "failed to send the reset email", "user_id", user.ID, "error", err)
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (ap *appP) ResetPasswordController(ctx *gin.Context) {
+	var req ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	payload, err := ap.TokenCreator.Verify(req.Token)
+	if err != nil || payload.Purpose != token.PurposeReset {
+		ctx.Error(ErrInvalidResetToken)
+		return
+	}
+
+	password, err := utils.GenerateHash(req.Password)
+	if err != nil {
+		ctx.Error(apperror.Internal("failed to hash the password", err))
+		return
+	}
+
+	// the reset tokens are single use, the token is claimed (added to the revocation list) before the password changes
+	// so two concurrent resets with the same token can't both succeed, a failed reset needs a new token
+	claimed, err := ap.RDBStore.RevocationRepository.Claim(ctx, payload.Id.String(), payload.ExpiredAt)
+	if err != nil {
+		ctx.Error(apperror.Internal("failed to claim the reset token", err))
+		return
+	}
+	if !claimed {
+		ctx.Error(ErrResetTokenUsed)
+		return
+	}
+
+	err = ap.DBStore.UserRepository.UpdatePassword(ctx, payload.UserId, password)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// whoever knew the old password is logged out
+	if _, err := RevokeUserSessions(ctx, &ap.AppC, payload.UserId); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "Password has been reset successfully"})
+}
@@ -0,0 +1,12 @@
+package controllers
+
+// here we put all the request and response types for the password controller
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"reset_token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
@@ -0,0 +1,60 @@
+package controllers_test
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/ayehia0/org/pkg/controllers"
+)
+
+func TestResetPassword(t *testing.T) {
+	app := newTestApp(t)
+	app.signup("alice", "alice@example.com", "password")
+	session := app.login("alice@example.com", "password")
+
+	call[controllers.MessageResponse](app, http.MethodPost, "/password/forgot", "", controllers.ForgotPasswordRequest{Email: "alice@example.com"}, http.StatusOK)
+	resetToken := app.mailedToken("alice@example.com")
+	call[controllers.MessageResponse](app, http.MethodPost, "/password/reset", "", controllers.ResetPasswordRequest{Token: resetToken, Password: "new password"}, http.StatusOK)
+
+	// the old password and the sessions opened with it don't work anymore
+	expectProblem(app, app.do(http.MethodPost, "/login", "", controllers.LoginRequest{Email: "alice@example.com", Password: "password"}), http.StatusUnauthorized, "invalid_credentials")
+	if rec := app.do(http.MethodGet, "/me", session.AccessToken, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("the session opened before the reset still works: %d", rec.Code)
+	}
+	app.login("alice@example.com", "new password")
+
+	// the token is single use
+	rec := app.do(http.MethodPost, "/password/reset", "", controllers.ResetPasswordRequest{Token: resetToken, Password: "another password"})
+	expectProblem(app, rec, http.StatusUnauthorized, "reset_token_used")
+}
+
+func TestResetPasswordConcurrently(t *testing.T) {
+	app := newTestApp(t)
+	app.signup("alice", "alice@example.com", "password")
+
+	call[controllers.MessageResponse](app, http.MethodPost, "/password/forgot", "", controllers.ForgotPasswordRequest{Email: "alice@example.com"}, http.StatusOK)
+	resetToken := app.mailedToken("alice@example.com")
+
+	// whoever holds the token can only use it once, even racing with themselves
+	var wg sync.WaitGroup
+	codes := make([]int, 10)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = app.do(http.MethodPost, "/password/reset", "", controllers.ResetPasswordRequest{Token: resetToken, Password: "new password"}).Code
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, code := range codes {
+		if code == http.StatusOK {
+			succeeded++
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d resets succeeded with the same token, want 1: %v", succeeded, codes)
+	}
+}
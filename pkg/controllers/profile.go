@@ -0,0 +1,247 @@
+package controllers
+
+import (
+	"net/http"
+
+	types "github.com/ayehia0/org/pkg/api"
+	api "github.com/ayehia0/org/pkg/api/middleware"
+	"github.com/ayehia0/org/pkg/apperror"
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/token"
+	"github.com/ayehia0/org/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// here we define all the controllers for the authenticated user to manage his own account
+type ProfileController interface {
+	GetProfileController(ctx *gin.Context)     // get the profile of the authenticated user
+	UpdateProfileController(ctx *gin.Context)  // update the profile
+	ChangePasswordController(ctx *gin.Context) // change the password and log out the other sessions
+	DeleteAccountController(ctx *gin.Context)  // delete the account and leave every organization
+}
+
+type appMe struct {
+	types.AppC
+}
+
+func NewProfileController(appC *types.AppC) ProfileController {
+	return &appMe{AppC: *appC}
+}
+
+func (am *appMe) GetProfileController(ctx *gin.Context) {
+	user, ok := am.authenticatedUser(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, returnProfileResponse(user))
+}
+
+func (am *appMe) UpdateProfileController(ctx *gin.Context) {
+	var req UpdateProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	user, ok := am.authenticatedUser(ctx)
+	if !ok {
+		return
+	}
+
+	if err := am.DBStore.UserRepository.UpdateName(ctx, user.ID, req.Name); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// the name is copied in the members of the organizations
+	if err := am.DBStore.OrganizationRepository.UpdateMemberName(ctx, user.ID, req.Name); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	user.Name = req.Name
+	ctx.JSON(http.StatusOK, returnProfileResponse(user))
+}
+
+func (am *appMe) ChangePasswordController(ctx *gin.Context) {
+	var req ChangePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	user, ok := am.authenticatedUser(ctx)
+	if !ok {
+		return
+	}
+
+	if err := utils.ComparePasswords(req.CurrentPassword, user.Password); err != nil {
+		ctx.Error(ErrInvalidCredentials)
+		return
+	}
+
+	password, err := utils.GenerateHash(req.NewPassword)
+	if err != nil {
+		ctx.Error(apperror.Internal("failed to hash the password", err))
+		return
+	}
+
+	if err := am.DBStore.UserRepository.UpdatePassword(ctx, user.ID, password); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// every other session is logged out, the current one keeps working
+	payload := ctx.MustGet(api.AuthPayloadKey).(*token.Payload)
+	currentFamily := ""
+	if current, err := am.DBStore.SessionRepository.FindByAccessTokenID(ctx, payload.Id.String()); err == nil {
+		currentFamily = sessionFamilyID(current)
+	}
+
+	sessions, err := am.DBStore.SessionRepository.FindByUserID(ctx, user.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	revoked := 0
+	for i := range sessions {
+		if sessionFamilyID(&sessions[i]) == currentFamily {
+			continue
+		}
+		if err := revokeSessionFamily(ctx, &am.AppC, &sessions[i]); err != nil {
+			ctx.Error(err)
+			return
+		}
+		revoked++
+	}
+
+	ctx.JSON(http.StatusOK, ChangePasswordResponse{
+		Message:         "Password has been changed successfully",
+		RevokedSessions: revoked,
+	})
+}
+
+func (am *appMe) DeleteAccountController(ctx *gin.Context) {
+	var req DeleteAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	user, ok := am.authenticatedUser(ctx)
+	if !ok {
+		return
+	}
+
+	if err := utils.ComparePasswords(req.Password, user.Password); err != nil {
+		ctx.Error(ErrInvalidCredentials)
+		return
+	}
+
+	// the organizations owned by the user are handed over before leaving them
+	orgs, err := am.DBStore.OrganizationRepository.FindByMember(ctx, user.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	for i := range orgs {
+		if err := clearTransferOf(ctx, &am.AppC, &orgs[i], user.ID); err != nil {
+			ctx.Error(err)
+			return
+		}
+		if memberAccessLevel(&orgs[i], user.ID) != models.AccessLevelOwner {
+			continue
+		}
+		if err := handOverOrganization(ctx, &am.AppC, &orgs[i], user.ID); err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+
+	if err := am.DBStore.OrganizationRepository.RemoveMemberFromAll(ctx, user.ID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if _, err := RevokeUserSessions(ctx, &am.AppC, user.ID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	payload := ctx.MustGet(api.AuthPayloadKey).(*token.Payload)
+	if err := am.RDBStore.RevocationRepository.Revoke(ctx, payload.Id.String(), payload.ExpiredAt); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := am.DBStore.UserRepository.Delete(ctx, user.ID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "Account has been deleted successfully"})
+}
+
+// helper function to get the user behind the access token
+func (am *appMe) authenticatedUser(ctx *gin.Context) (*models.User, bool) {
+	payload := ctx.MustGet(api.AuthPayloadKey).(*token.Payload)
+	user, err := am.DBStore.UserRepository.FindByID(ctx, payload.UserId)
+	if err != nil {
+		ctx.Error(err)
+		return nil, false
+	}
+	return user, true
+}
+
+// helper function to make sure an organization keeps an owner when one of its owners goes away
+// the most privileged remaining member becomes the owner (and the creator), an organization left without members is deleted
+func handOverOrganization(ctx *gin.Context, appC *types.AppC, org *models.Organization, userID string) error {
+	var successor *models.Member
+	for i := range org.Members {
+		member := &org.Members[i]
+		if member.ID == userID {
+			continue
+		}
+		if successor == nil || roleRank[member.AccessLevel] > roleRank[successor.AccessLevel] {
+			successor = member
+		}
+	}
+
+	if successor == nil {
+		return appC.DBStore.OrganizationRepository.Delete(ctx, org.ID)
+	}
+
+	if successor.AccessLevel != models.AccessLevelOwner {
+		err := appC.DBStore.OrganizationRepository.UpdateMemberAccessLevel(ctx, org.ID, successor.ID, models.AccessLevelOwner)
+		if err != nil {
+			return err
+		}
+	}
+
+	if org.Creator == userID {
+		return appC.DBStore.OrganizationRepository.SetCreator(ctx, org.ID, successor.ID)
+	}
+	return nil
+}
+
+// helper function to drop the pending transfer proposed by (or to) a user who goes away, a stale proposal can't hand over the organization
+func clearTransferOf(ctx *gin.Context, appC *types.AppC, org *models.Organization, userID string) error {
+	transfer := org.PendingTransfer
+	if transfer == nil || (transfer.From != userID && transfer.To != userID) {
+		return nil
+	}
+	return appC.DBStore.OrganizationRepository.ClearTransfer(ctx, org.ID)
+}
+
+func returnProfileResponse(user *models.User) ProfileResponse {
+	return ProfileResponse{
+		ID:            user.ID,
+		Name:          user.Name,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		MFAEnabled:    user.MFA.Enabled,
+	}
+}
@@ -0,0 +1,29 @@
+package controllers
+
+// here we put all the request and response types for the profile controller
+
+type ProfileResponse struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	MFAEnabled    bool   `json:"mfa_enabled"`
+}
+
+type UpdateProfileRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type ChangePasswordResponse struct {
+	Message         string `json:"message"`
+	RevokedSessions int    `json:"revoked_sessions"`
+}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	types "github.com/ayehia0/org/pkg/api"
+	api "github.com/ayehia0/org/pkg/api/middleware"
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/database/mongodb/repository"
+	"github.com/ayehia0/org/pkg/token"
+	"github.com/gin-gonic/gin"
+)
+
+// here we define all the controllers to let the user manage his own sessions (logged in devices)
+type SessionController interface {
+	ListSessionsController(ctx *gin.Context)  // list the active sessions of the authenticated user
+	GetSessionController(ctx *gin.Context)    // inspect a single session
+	DeleteSessionController(ctx *gin.Context) // log out a single session
+	LogoutAllController(ctx *gin.Context)     // log out every session of the user
+}
+
+type appS struct {
+	types.AppC
+}
+
+func NewSessionController(appC *types.AppC) SessionController {
+	return &appS{AppC: *appC}
+}
+
+func (as *appS) ListSessionsController(ctx *gin.Context) {
+	payload := ctx.MustGet(api.AuthPayloadKey).(*token.Payload)
+
+	sessions, err := as.DBStore.SessionRepository.FindByUserID(ctx, payload.UserId)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	resp := []SessionResponse{}
+	for i := range sessions {
+		// the expired sessions are useless, they will be replaced by a new login
+		if time.Now().After(sessions[i].RefreshTokenExpires) {
+			continue
+		}
+		resp = append(resp, returnSessionResponse(&sessions[i], payload))
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (as *appS) GetSessionController(ctx *gin.Context) {
+	payload := ctx.MustGet(api.AuthPayloadKey).(*token.Payload)
+
+	session, ok := as.ownedSession(ctx, payload)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, returnSessionResponse(session, payload))
+}
+
+func (as *appS) DeleteSessionController(ctx *gin.Context) {
+	payload := ctx.MustGet(api.AuthPayloadKey).(*token.Payload)
+
+	session, ok := as.ownedSession(ctx, payload)
+	if !ok {
+		return
+	}
+
+	if err := revokeSessionFamily(ctx, &as.AppC, session); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "Session has been revoked successfully"})
+}
+
+func (as *appS) LogoutAllController(ctx *gin.Context) {
+	payload := ctx.MustGet(api.AuthPayloadKey).(*token.Payload)
+
+	count, err := RevokeUserSessions(ctx, &as.AppC, payload.UserId)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// the access token of this request might not belong to any session
+	err = as.RDBStore.RevocationRepository.Revoke(ctx, payload.Id.String(), payload.ExpiredAt)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, LogoutAllResponse{
+		Message:  "User has been logged out from all the sessions successfully",
+		Sessions: count,
+	})
+}
+
+// helper function to load the session given in the path, only the active sessions of the user are visible
+func (as *appS) ownedSession(ctx *gin.Context, payload *token.Payload) (*models.Session, bool) {
+	session, err := as.DBStore.SessionRepository.FindByID(ctx, ctx.Param("id"))
+	if err != nil || session.UserID != payload.UserId || session.ReplacedBy != "" {
+		ctx.Error(repository.ErrSessionNotFound)
+		return nil, false
+	}
+	return session, true
+}
+
+// helper function to kill the sessions right away: the refresh tokens are dropped from redis and the access tokens are denied
+func revokeSessions(ctx context.Context, appC *types.AppC, sessions []models.Session) error {
+	for _, session := range sessions {
+		if err := appC.RDBStore.SessionRepository.DeleteSession(ctx, session.RefreshToken); err != nil {
+			return err
+		}
+		if err := appC.RDBStore.RevocationRepository.Revoke(ctx, session.AccessTokenID, session.AccessTokenExpires); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revoke all the sessions of a user, returns the number of active sessions revoked
+// it is also used by the admin commands, so it doesn't depend on a request
+func RevokeUserSessions(ctx context.Context, appC *types.AppC, userID string) (int, error) {
+	// the rotated sessions are included, their access tokens are still valid until they expire
+	sessions, err := appC.DBStore.SessionRepository.FindAllByUserID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := revokeSessions(ctx, appC, sessions); err != nil {
+		return 0, err
+	}
+
+	if err := appC.DBStore.SessionRepository.DeleteByUserID(ctx, userID); err != nil {
+		return 0, err
+	}
+
+	active := 0
+	for i := range sessions {
+		if sessions[i].ReplacedBy == "" {
+			active++
+		}
+	}
+	return active, nil
+}
+
+// helper function to revoke a session along with the sessions it was rotated from/to, and delete them from the database
+func revokeSessionFamily(ctx *gin.Context, appC *types.AppC, session *models.Session) error {
+	sessions, err := appC.DBStore.SessionRepository.FindByFamilyID(ctx, sessionFamilyID(session))
+	if err != nil {
+		return err
+	}
+
+	// the sessions created before rotation was introduced don't have a family
+	if session.FamilyID == "" {
+		sessions = append(sessions, *session)
+	}
+
+	if err := revokeSessions(ctx, appC, sessions); err != nil {
+		return err
+	}
+
+	if err := appC.DBStore.SessionRepository.DeleteByFamilyID(ctx, sessionFamilyID(session)); err != nil {
+		return err
+	}
+	return appC.DBStore.SessionRepository.Delete(ctx, session.ID)
+}
+
+// the sessions created before rotation was introduced are the root of their own family
+func sessionFamilyID(session *models.Session) string {
+	if session.FamilyID == "" {
+		return session.ID
+	}
+	return session.FamilyID
+}
+
+func returnSessionResponse(session *models.Session, payload *token.Payload) SessionResponse {
+	return SessionResponse{
+		ID:         session.ID,
+		UserAgent:  session.UserAgent,
+		IP:         session.IP,
+		CreatedAt:  session.CreatedAt,
+		LastUsedAt: session.LastUsedAt,
+		ExpiresAt:  session.RefreshTokenExpires,
+		Current:    session.AccessTokenID == payload.Id.String(),
+	}
+}
@@ -0,0 +1,21 @@
+package controllers
+
+import "time"
+
+// here we put all the request and response types for the session controller
+
+// a session as seen by its owner, the tokens are never returned
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+type LogoutAllResponse struct {
+	Message  string `json:"message"`
+	Sessions int    `json:"sessions"`
+}
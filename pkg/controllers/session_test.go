@@ -0,0 +1,53 @@
+package controllers_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	api "github.com/ayehia0/org/pkg/api/middleware"
+	"github.com/ayehia0/org/pkg/controllers"
+)
+
+func TestSessionLastUse(t *testing.T) {
+	interval := api.SessionTouchInterval
+	api.SessionTouchInterval = 0
+	t.Cleanup(func() { api.SessionTouchInterval = interval })
+
+	app := newTestApp(t)
+	app.signup("alice", "alice@example.com", "password")
+	tokens := app.login("alice@example.com", "password")
+
+	sessions := call[[]controllers.SessionResponse](app, http.MethodGet, "/me/sessions", tokens.AccessToken, nil, http.StatusOK)
+	if len(sessions) != 1 {
+		t.Fatalf("got %d sessions, want 1", len(sessions))
+	}
+	created := sessions[0].LastUsedAt
+
+	// every authenticated request uses the session
+	time.Sleep(10 * time.Millisecond)
+	call[controllers.ProfileResponse](app, http.MethodGet, "/me", tokens.AccessToken, nil, http.StatusOK)
+	sessions = call[[]controllers.SessionResponse](app, http.MethodGet, "/me/sessions", tokens.AccessToken, nil, http.StatusOK)
+	if !sessions[0].LastUsedAt.After(created) {
+		t.Fatalf("the last use %s hasn't moved since the login %s", sessions[0].LastUsedAt, created)
+	}
+}
+
+func TestLogoutAllRevokesTheRotatedSessions(t *testing.T) {
+	app := newTestApp(t)
+	app.signup("alice", "alice@example.com", "password")
+	tokens := app.login("alice@example.com", "password")
+	next := call[controllers.RefreshTokenResponse](app, http.MethodPost, "/refresh-token", "", controllers.RefreshTokenRequest{RefreshToken: tokens.RefreshToken}, http.StatusOK)
+
+	resp := call[controllers.LogoutAllResponse](app, http.MethodPost, "/logout-all", next.AccessToken, nil, http.StatusOK)
+	if resp.Sessions != 1 {
+		t.Fatalf("got %d revoked sessions, want 1", resp.Sessions)
+	}
+
+	// the access token issued before the rotation is still within its lifetime
+	for _, accessToken := range []string{tokens.AccessToken, next.AccessToken} {
+		if rec := app.do(http.MethodGet, "/me", accessToken, nil); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("the access token still works: %d %s", rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,8 @@
+package controllers
+
+// here we put the response types shared by the controllers
+
+// the response of the actions that only tell whether they succeeded
+type MessageResponse struct {
+	Message string `json:"message"`
+}
@@ -2,11 +2,20 @@ package controllers
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	types "github.com/ayehia0/org/pkg/api"
+	api "github.com/ayehia0/org/pkg/api/middleware"
+	"github.com/ayehia0/org/pkg/apperror"
 	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/database/mongodb/repository"
+	"github.com/ayehia0/org/pkg/mailer"
+	"github.com/ayehia0/org/pkg/metrics"
+	"github.com/ayehia0/org/pkg/token"
+	"github.com/ayehia0/org/pkg/tracing"
 	"github.com/ayehia0/org/pkg/utils"
 	"github.com/gin-gonic/gin"
 )
@@ -24,6 +33,18 @@ type UserController interface {
 
 	// revoke refresh token
 	RevokeRefreshTokenController(ctx *gin.Context)
+
+	// revoke the current access token and its session
+	LogoutController(ctx *gin.Context)
+
+	// the second step of the login when the two factor authentication is enabled
+	LoginMFAController(ctx *gin.Context)
+
+	// verify the email using the token sent at signup
+	VerifyEmailController(ctx *gin.Context)
+
+	// send the verification email again
+	ResendVerificationController(ctx *gin.Context)
 }
 
 type appU struct {
@@ -41,30 +62,49 @@ func (au *appU) SignupController(ctx *gin.Context) {
 
 	// bind the request
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, utils.ErrorResp(err))
+		ctx.Error(apperror.InvalidRequest(err))
 		return
 	}
 	// hashing the password
 	password, err := utils.GenerateHash(req.Password)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, utils.ErrorResp(errors.New("failed to hash the password")))
+		ctx.Error(apperror.Internal("failed to hash the password", err))
 		return
 	}
 
 	// save the user to the database
-	err = au.DBStore.UserRepository.Create(ctx, &models.User{
+	user := &models.User{
 		Name:     req.Name,
 		Email:    req.Email,
 		Password: password,
-	})
+	}
+	err = au.DBStore.UserRepository.Create(ctx, user)
 
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, utils.ErrorResp(err))
+		ctx.Error(err)
 		return
 	}
 
+	// the user might have been invited to organizations before having an account
+	invitations, err := au.DBStore.InvitationRepository.AttachUser(ctx, user.Email, user.ID)
+	if err != nil {
+		ctx.Error(apperror.Internal("failed to attach the pending invitations", err))
+		return
+	}
+
+	// the account is created anyway, the email can be sent again using the resend endpoint
+	emailSent := true
+	if err := au.sendVerificationEmail(ctx, user); err != nil {
+		emailSent = false
+		au.Logger.ErrorContext(ctx, "failed to send the verification email", "user_id", user.ID, "error", err)
+	}
+
 	// for testing return the request
-	ctx.JSON(http.StatusOK, gin.H{"message": "User has been created successfully"})
+	ctx.JSON(http.StatusOK, SignupResponse{
+		Message:               "User has been created successfully",
+		PendingInvitations:    invitations,
+		VerificationEmailSent: emailSent,
+	})
 }
 
 func (au *appU) LoginController(ctx *gin.Context) {
@@ -72,7 +112,15 @@ func (au *appU) LoginController(ctx *gin.Context) {
 
 	// bind the request
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, utils.ErrorResp(err))
+		ctx.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	// the lock is checked before the user is looked up and the unknown emails are counted like the wrong passwords,
+	// so the lock doesn't tell whether the email exists
+	if lockedFor := au.loginLockedFor(ctx, req.Email); lockedFor > 0 {
+		metrics.ObserveLogin(metrics.ResultFailure)
+		api.TooManyRequests(ctx, lockedFor, ErrAccountLocked)
 		return
 	}
 
@@ -80,150 +128,499 @@ func (au *appU) LoginController(ctx *gin.Context) {
 	user, err := au.DBStore.UserRepository.FindByEmail(ctx, req.Email)
 
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, utils.ErrorResp(errors.New("failed to get the user")))
+		metrics.ObserveLogin(metrics.ResultFailure)
+		// the unknown emails get the same answer as the wrong passwords
+		if errors.Is(err, repository.ErrUserNotFound) {
+			au.loginFailed(ctx, lockoutKey(req.Email), req.Email)
+			err = ErrInvalidCredentials
+		}
+		ctx.Error(err)
 		return
 	}
 
-	// compare the password
-	if err := utils.ComparePasswords(req.Password, user.Password); err != nil {
-		ctx.JSON(http.StatusUnauthorized, utils.ErrorResp(errors.New("invalid credentials")))
+	// compare the password, bcrypt is slow on purpose so it gets its own span
+	_, span := tracing.Start(ctx, "password.Compare")
+	err = utils.ComparePasswords(req.Password, user.Password)
+	span.End()
+	if err != nil {
+		metrics.ObserveLogin(metrics.ResultFailure)
+		au.loginFailed(ctx, lockoutKey(req.Email), req.Email)
+		ctx.Error(ErrInvalidCredentials)
 		return
 	}
+	au.loginSucceeded(ctx, lockoutKey(req.Email))
 
-	// create a access token
-	userAccessToken, payloadAccess, err := au.TokenCreator.Create(user.ID, au.AppConfig.TokenAccessExpiration)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, utils.ErrorResp(errors.New("failed to create a token")))
+	if user.Disabled {
+		metrics.ObserveLogin(metrics.ResultFailure)
+		ctx.Error(ErrAccountDisabled)
 		return
 	}
 
-	// create a refresh token
-	userRefreshToken, payloadrefresh, err := au.TokenCreator.Create(user.ID, au.AppConfig.TokenRefreshExpiration)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, utils.ErrorResp(errors.New("failed to create a token")))
+	if au.AppConfig.RequireVerifiedLogin && !user.EmailVerified {
+		metrics.ObserveLogin(metrics.ResultFailure)
+		ctx.Error(ErrEmailNotVerified)
 		return
 	}
 
-	// Save the session to the database
-	session := &models.Session{
-		ID:                  payloadrefresh.Id.String(),
-		UserID:              user.ID,
-		AccessToken:         userAccessToken,
-		RefreshToken:        userRefreshToken,
-		AccessTokenExpires:  payloadAccess.ExpiredAt,
-		RefreshTokenExpires: payloadrefresh.ExpiredAt,
+	// the session is only created after the second factor is verified
+	if user.MFA.Enabled {
+		mfaToken, _, err := au.TokenCreator.CreateWithPurpose(ctx, user.ID, token.PurposeMFA, au.AppConfig.MFAChallengeExpiration)
+		if err != nil {
+			ctx.Error(apperror.Internal("failed to create a token", err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+			Message:     "Two factor authentication code is required",
+		})
+		return
+	}
+
+	au.login(ctx, user)
+}
+
+func (au *appU) LoginMFAController(ctx *gin.Context) {
+	var req LoginMFARequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	// the mfa token proves that the password has been checked
+	payload, err := au.TokenCreator.Verify(req.MFAToken)
+	if err != nil || payload.Purpose != token.PurposeMFA {
+		ctx.Error(ErrInvalidToken)
+		return
 	}
-	err = au.DBStore.SessionRepository.Create(ctx, session)
+
+	user, err := au.DBStore.UserRepository.FindByID(ctx, payload.UserId)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, utils.ErrorResp(errors.New("failed to create a session")))
+		ctx.Error(ErrInvalidToken)
 		return
 	}
 
-	// Also save the refresh token to the redis database
-	err = au.RDBStore.SessionRepository.CreateSession(ctx, session)
+	// the user might have been disabled since the first step
+	if user.Disabled {
+		metrics.ObserveLogin(metrics.ResultFailure)
+		ctx.Error(ErrAccountDisabled)
+		return
+	}
+
+	// the wrong codes lock the login of the account like the wrong passwords
+	if lockedFor := au.loginLockedFor(ctx, user.Email); lockedFor > 0 {
+		metrics.ObserveLogin(metrics.ResultFailure)
+		api.TooManyRequests(ctx, lockedFor, ErrAccountLocked)
+		return
+	}
+
+	// each code (and recovery code) is accepted once
+	ok := false
+	if user.MFA.Enabled {
+		ok, err = useMFA(ctx, &au.AppC, user, req.Code, req.RecoveryCode)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+	if !ok {
+		metrics.ObserveLogin(metrics.ResultFailure)
+		au.loginFailed(ctx, mfaLockoutKey(user.ID), user.Email)
+		ctx.Error(ErrInvalidCode)
+		return
+	}
+	au.loginSucceeded(ctx, mfaLockoutKey(user.ID))
+
+	au.login(ctx, user)
+}
+
+// helper function to start a new session for the user once authenticated
+func (au *appU) login(ctx *gin.Context, user *models.User) {
+	// create the tokens of a brand new token family
+	session, err := au.newSession(ctx, user.ID, "")
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, utils.ErrorResp(errors.New("failed to save session to the redis")))
+		ctx.Error(apperror.Internal("failed to create a token", err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, returnRefreshTokenResponse(session.RefreshToken, userAccessToken))
+	if err := au.saveSession(ctx, session); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	metrics.ObserveLogin(metrics.ResultSuccess)
+	ctx.JSON(http.StatusOK, returnRefreshTokenResponse(session.RefreshToken, session.AccessToken))
 }
 
 func (au *appU) RefreshTokenController(ctx *gin.Context) {
 	var req RefreshTokenRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, utils.ErrorResp(err))
+		ctx.Error(apperror.InvalidRequest(err))
 		return
 	}
 
-	// verify the refresh token
+	// verify the refresh token, the ones issued before the purposes were introduced have none
+	// they are still accepted as long as their session exists (the lookup below), the other tokens have no session
 	payload, err := au.TokenCreator.Verify(req.RefreshToken)
-	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, utils.ErrorResp(errors.New("invalid token")))
+	if err != nil || (payload.Purpose != token.PurposeRefresh && payload.Purpose != "") {
+		metrics.ObserveRefresh(metrics.ResultFailure)
+		ctx.Error(ErrInvalidToken)
 		return
 	}
 
-	// check if the token is valid in the redis database first
-	sessionInRedis, err := au.RDBStore.SessionRepository.GetSessionByID(ctx, req.RefreshToken)
+	// check if the token is valid in the redis database first, only the latest token of a family is cached
+	session, err := au.RDBStore.SessionRepository.GetSessionByID(ctx, req.RefreshToken)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, utils.ErrorResp(err))
+		ctx.Error(err)
 		return
 	}
 
-	// if the token is found
-	if sessionInRedis != nil {
-		err = isSessionValid(sessionInRedis)
+	// fallback to the database
+	if session == nil {
+		session, err = au.DBStore.SessionRepository.FindByID(ctx, payload.Id.String())
 		if err != nil {
-			ctx.JSON(http.StatusUnauthorized, utils.ErrorResp(err))
+			metrics.ObserveRefresh(metrics.ResultFailure)
+			ctx.Error(ErrInvalidToken)
 			return
 		}
+	}
 
-		// create a new access token
-		token, _, err := au.TokenCreator.Create(sessionInRedis.ID, au.AppConfig.TokenAccessExpiration)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, utils.ErrorResp(errors.New("failed to create a token")))
-			return
+	err = isSessionValid(session, payload.UserId)
+	if err != nil {
+		metrics.ObserveRefresh(metrics.ResultFailure)
+		ctx.Error(err)
+		return
+	}
+
+	// the token has been used before, someone is replaying it
+	if session.ReplacedBy != "" {
+		metrics.ObserveRefresh(metrics.ResultReused)
+		au.refreshTokenReused(ctx, session)
+		return
+	}
+
+	// the user might have been disabled since the login
+	user, err := au.DBStore.UserRepository.FindByID(ctx, session.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			metrics.ObserveRefresh(metrics.ResultFailure)
+			err = ErrInvalidToken
 		}
-		ctx.JSON(http.StatusOK, returnRefreshTokenResponse(sessionInRedis.RefreshToken, token))
+		ctx.Error(err)
+		return
+	}
+	if user.Disabled {
+		metrics.ObserveRefresh(metrics.ResultFailure)
+		ctx.Error(ErrAccountDisabled)
 		return
 	}
 
-	// get the session from the database
-	session, err := au.DBStore.SessionRepository.FindByID(ctx, payload.Id.String())
+	// rotate the session: create the next one in the family and retire the current one
+	next, err := au.newSession(ctx, session.UserID, sessionFamilyID(session))
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, utils.ErrorResp(err))
+		ctx.Error(apperror.Internal("failed to create a token", err))
 		return
 	}
+	if !session.CreatedAt.IsZero() {
+		next.CreatedAt = session.CreatedAt
+	}
 
-	err = isSessionValid(session)
+	// the next session is saved first, the current one is only retired once its replacement exists
+	// otherwise a failed save would leave the user with a rotated (so reused on retry) token and no new one
+	if err := au.saveSession(ctx, next); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	err = au.DBStore.SessionRepository.MarkRotated(ctx, session.ID, next.ID)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, utils.ErrorResp(err))
+		// another refresh with the same token won the race, the revoked family includes the next session
+		if errors.Is(err, repository.ErrSessionRotated) {
+			metrics.ObserveRefresh(metrics.ResultReused)
+			au.refreshTokenReused(ctx, session)
+			return
+		}
+		// the current session is still valid, the next one is dropped
+		ctx.Error(errors.Join(err, au.discardSession(ctx, next)))
 		return
 	}
-	// create a new access token
-	token, payload, err := au.TokenCreator.Create(session.ID, au.AppConfig.TokenAccessExpiration)
+
+	err = au.RDBStore.SessionRepository.DeleteSession(ctx, session.RefreshToken)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, utils.ErrorResp(errors.New("failed to create a token")))
+		ctx.Error(err)
 		return
 	}
-	ctx.JSON(http.StatusOK, returnRefreshTokenResponse(session.RefreshToken, token))
+
+	metrics.ObserveRefresh(metrics.ResultSuccess)
+	ctx.JSON(http.StatusOK, returnRefreshTokenResponse(next.RefreshToken, next.AccessToken))
 }
 
 func (au *appU) RevokeRefreshTokenController(ctx *gin.Context) {
 	var req RevokeRefreshTokenRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, utils.ErrorResp(err))
+		ctx.Error(apperror.InvalidRequest(err))
 		return
 	}
 
 	// delete the refresh token from the redis database
 	err := au.RDBStore.SessionRepository.DeleteSession(ctx, req.RefreshToken)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, utils.ErrorResp(err))
+		ctx.Error(err)
 		return
 	}
 
-	// this is optional to delete the token from the database or now
-	// TODO: delete the session record from the database
+	// the session is removed from the database as well and its access token stops working right away
+	payload, err := au.TokenCreator.Verify(req.RefreshToken)
+	if err == nil {
+		session, err := au.DBStore.SessionRepository.FindByID(ctx, payload.Id.String())
+		if err == nil {
+			if err := revokeSessionFamily(ctx, &au.AppC, session); err != nil {
+				ctx.Error(err)
+				return
+			}
+		}
+	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Token has been revoked successfully"})
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "Token has been revoked successfully"})
+}
+
+func (au *appU) LogoutController(ctx *gin.Context) {
+	payload := ctx.MustGet(api.AuthPayloadKey).(*token.Payload)
+
+	// revoke the access token used for this request
+	err := au.RDBStore.RevocationRepository.Revoke(ctx, payload.Id.String(), payload.ExpiredAt)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// and the session it belongs to, if any
+	session, err := au.DBStore.SessionRepository.FindByAccessTokenID(ctx, payload.Id.String())
+	if err == nil {
+		if err := revokeSessionFamily(ctx, &au.AppC, session); err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "User has been logged out successfully"})
+}
+
+func (au *appU) VerifyEmailController(ctx *gin.Context) {
+	var req VerifyEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	payload, err := au.TokenCreator.Verify(req.Token)
+	if err != nil || payload.Purpose != token.PurposeVerify {
+		ctx.Error(ErrInvalidVerificationToken)
+		return
+	}
+
+	if err := au.DBStore.UserRepository.MarkEmailVerified(ctx, payload.UserId); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, MessageResponse{Message: "Email has been verified successfully"})
+}
+
+func (au *appU) ResendVerificationController(ctx *gin.Context) {
+	var req ResendVerificationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	// the response is the same whether the user exists or not, so the emails can't be enumerated
+	resp := MessageResponse{Message: "If the email exists and isn't verified, a verification email has been sent to it"}
+
+	user, err := au.DBStore.UserRepository.FindByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			ctx.JSON(http.StatusOK, resp)
+			return
+		}
+		ctx.Error(apperror.Internal("failed to get the user", err))
+		return
+	}
+
+	if !user.EmailVerified {
+		if err := au.sendVerificationEmail(ctx, user); err != nil {
+			ctx.Error(apperror.Internal("failed to send the email", err))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// helper function to email a verification token to the user
+func (au *appU) sendVerificationEmail(ctx *gin.Context, user *models.User) error {
+	verifyToken, _, err := au.TokenCreator.CreateWithPurpose(ctx, user.ID, token.PurposeVerify, au.AppConfig.EmailVerificationExpiration)
+	if err != nil {
+		return err
+	}
+
+	return au.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the following token to verify your email, it expires in %s:\n\n%s\n",
+			user.Name, au.AppConfig.EmailVerificationExpiration, verifyToken),
+	})
 }
 
 // helper function to check if the session is valid
-func isSessionValid(session *models.Session) error {
+func isSessionValid(session *models.Session, userID string) error {
 	// if the user isn't the owner of the session
-	if session.UserID != session.UserID {
-		return errors.New("Session does not belong to the user")
+	if session.UserID != userID {
+		return ErrSessionMismatch
 	}
 
 	if time.Now().After(session.RefreshTokenExpires) {
-		return errors.New("Session has been expired before!")
+		return ErrSessionExpired
+	}
+
+	return nil
+}
+
+// helper function to create the access and refresh tokens of a session, an empty family starts a new family
+func (au *appU) newSession(ctx *gin.Context, userID, familyID string) (*models.Session, error) {
+	accessToken, payloadAccess, err := au.TokenCreator.Create(ctx, userID, au.AppConfig.TokenAccessExpiration)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, payloadRefresh, err := au.TokenCreator.CreateWithPurpose(ctx, userID, token.PurposeRefresh, au.AppConfig.TokenRefreshExpiration)
+	if err != nil {
+		return nil, err
+	}
+
+	// the session id is the same as the refresh token id
+	if familyID == "" {
+		familyID = payloadRefresh.Id.String()
+	}
+
+	now := time.Now()
+	return &models.Session{
+		ID:                  payloadRefresh.Id.String(),
+		UserID:              userID,
+		FamilyID:            familyID,
+		AccessToken:         accessToken,
+		AccessTokenID:       payloadAccess.Id.String(),
+		RefreshToken:        refreshToken,
+		AccessTokenExpires:  payloadAccess.ExpiredAt,
+		RefreshTokenExpires: payloadRefresh.ExpiredAt,
+		UserAgent:           ctx.Request.UserAgent(),
+		IP:                  ctx.ClientIP(),
+		CreatedAt:           now,
+		LastUsedAt:          now,
+	}, nil
+}
+
+// helper function to save the session to the database and redis
+func (au *appU) saveSession(ctx *gin.Context, session *models.Session) error {
+	err := au.DBStore.SessionRepository.Create(ctx, session)
+	if err != nil {
+		return apperror.Internal("failed to create a session", err)
 	}
 
+	// Also save the refresh token to the redis database
+	err = au.RDBStore.SessionRepository.CreateSession(ctx, session)
+	if err != nil {
+		return apperror.Internal("failed to save session to the redis", err)
+	}
 	return nil
 }
 
+// helper function to drop a session that has been saved but not handed out
+func (au *appU) discardSession(ctx *gin.Context, session *models.Session) error {
+	if err := au.RDBStore.SessionRepository.DeleteSession(ctx, session.RefreshToken); err != nil {
+		return err
+	}
+	return au.DBStore.SessionRepository.Delete(ctx, session.ID)
+}
+
+// the lockout is keyed by the email as typed by the user, whatever its case
+func lockoutKey(email string) string {
+	return "login:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// the wrong codes are counted per user, apart from the wrong passwords so the right password doesn't forget them
+func mfaLockoutKey(userID string) string {
+	return "mfa:" + userID
+}
+
+// helper function to tell how long the login of an email is locked, the login isn't blocked when the lock can't be checked
+func (au *appU) loginLockedFor(ctx *gin.Context, email string) time.Duration {
+	if au.AppConfig.Lockout.MaxFailures <= 0 {
+		return 0
+	}
+
+	lockedFor, err := au.RDBStore.LockoutRepository.LockedFor(ctx, lockoutKey(email))
+	if err != nil {
+		au.Logger.ErrorContext(ctx, "failed to check the lockout", "error", err)
+		return 0
+	}
+	return lockedFor
+}
+
+// helper function to count a failed attempt (a wrong password or a wrong code) under key, the login of the email is locked once there are too many of them
+func (au *appU) loginFailed(ctx *gin.Context, key, email string) {
+	lockout := au.AppConfig.Lockout
+	if lockout.MaxFailures <= 0 {
+		return
+	}
+
+	failures, err := au.RDBStore.LockoutRepository.RecordFailure(ctx, key, lockout.Window)
+	if err != nil {
+		au.Logger.ErrorContext(ctx, "failed to record the failed login", "error", err)
+		return
+	}
+	if failures < int64(lockout.MaxFailures) {
+		return
+	}
+
+	if err := au.RDBStore.LockoutRepository.Lock(ctx, lockoutKey(email), lockout.Duration); err != nil {
+		au.Logger.ErrorContext(ctx, "failed to lock the account", "error", err)
+		return
+	}
+	// the lock forgets the wrong passwords, the wrong codes are forgotten too
+	if key != lockoutKey(email) {
+		if err := au.RDBStore.LockoutRepository.Reset(ctx, key); err != nil {
+			au.Logger.ErrorContext(ctx, "failed to reset the failed logins", "error", err)
+		}
+	}
+	au.Logger.WarnContext(ctx, "account locked after too many failed logins", "email", email, "failures", failures, "duration", lockout.Duration.String())
+}
+
+// helper function to forget the failed attempts counted under key once the right password (or code) is given
+func (au *appU) loginSucceeded(ctx *gin.Context, key string) {
+	if au.AppConfig.Lockout.MaxFailures <= 0 {
+		return
+	}
+
+	if err := au.RDBStore.LockoutRepository.Reset(ctx, key); err != nil {
+		au.Logger.ErrorContext(ctx, "failed to reset the failed logins", "error", err)
+	}
+}
+
+// helper function to revoke every session of the family once a rotated refresh token is presented again
+func (au *appU) refreshTokenReused(ctx *gin.Context, session *models.Session) {
+	au.Logger.WarnContext(ctx, "refresh token reused, revoking the session family", "user_id", session.UserID, "family_id", session.FamilyID, "ip", ctx.ClientIP())
+	if err := revokeSessionFamily(ctx, &au.AppC, session); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Error(ErrRefreshTokenReused)
+}
+
 func returnRefreshTokenResponse(refreshToken, accessToken string) RefreshTokenResponse {
 	return RefreshTokenResponse{
 		AccessToken:  accessToken,
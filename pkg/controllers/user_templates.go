@@ -24,3 +24,17 @@ type RefreshTokenResponse struct {
 type RevokeRefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
+
+type VerifyEmailRequest struct {
+	Token string `json:"verification_token" binding:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type SignupResponse struct {
+	Message               string `json:"message"`
+	PendingInvitations    int64  `json:"pending_invitations"`
+	VerificationEmailSent bool   `json:"verification_email_sent"`
+}
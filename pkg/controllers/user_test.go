@@ -0,0 +1,165 @@
+package controllers_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ayehia0/org/pkg/controllers"
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/database/mongodb/repository"
+)
+
+func TestSignupLoginAndRefresh(t *testing.T) {
+	app := newTestApp(t)
+	app.signup("alice", "alice@example.com", "password")
+
+	tokens := app.login("alice@example.com", "password")
+	profile := call[controllers.ProfileResponse](app, http.MethodGet, "/me", tokens.AccessToken, nil, http.StatusOK)
+	if profile.Email != "alice@example.com" || !profile.EmailVerified {
+		t.Fatalf("unexpected profile: %+v", profile)
+	}
+
+	// the refresh token is rotated, the new pair works
+	next := call[controllers.RefreshTokenResponse](app, http.MethodPost, "/refresh-token", "", controllers.RefreshTokenRequest{RefreshToken: tokens.RefreshToken}, http.StatusOK)
+	if next.RefreshToken == tokens.RefreshToken || next.AccessToken == tokens.AccessToken {
+		t.Fatal("the tokens haven't been rotated")
+	}
+	call[controllers.ProfileResponse](app, http.MethodGet, "/me", next.AccessToken, nil, http.StatusOK)
+	call[controllers.RefreshTokenResponse](app, http.MethodPost, "/refresh-token", "", controllers.RefreshTokenRequest{RefreshToken: next.RefreshToken}, http.StatusOK)
+}
+
+func TestLoginWithWrongCredentials(t *testing.T) {
+	app := newTestApp(t)
+	app.signup("alice", "alice@example.com", "password")
+
+	// the unknown emails get the same answer as the wrong passwords
+	for _, req := range []controllers.LoginRequest{
+		{Email: "alice@example.com", Password: "wrong"},
+		{Email: "bob@example.com", Password: "password"},
+	} {
+		expectProblem(app, app.do(http.MethodPost, "/login", "", req), http.StatusUnauthorized, "invalid_credentials")
+	}
+}
+
+func TestRefreshTokenReuseRevokesTheSession(t *testing.T) {
+	app := newTestApp(t)
+	app.signup("alice", "alice@example.com", "password")
+
+	tokens := app.login("alice@example.com", "password")
+	next := call[controllers.RefreshTokenResponse](app, http.MethodPost, "/refresh-token", "", controllers.RefreshTokenRequest{RefreshToken: tokens.RefreshToken}, http.StatusOK)
+
+	// the rotated token is presented again, someone else might hold it
+	rec := app.do(http.MethodPost, "/refresh-token", "", controllers.RefreshTokenRequest{RefreshToken: tokens.RefreshToken})
+	expectProblem(app, rec, http.StatusUnauthorized, "refresh_token_reused")
+
+	// the whole family is revoked, the latest token doesn't work either
+	rec = app.do(http.MethodPost, "/refresh-token", "", controllers.RefreshTokenRequest{RefreshToken: next.RefreshToken})
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("the latest refresh token still works: %d %s", rec.Code, rec.Body.String())
+	}
+
+	// the other sessions of the user aren't affected
+	other := app.login("alice@example.com", "password")
+	call[controllers.RefreshTokenResponse](app, http.MethodPost, "/refresh-token", "", controllers.RefreshTokenRequest{RefreshToken: other.RefreshToken}, http.StatusOK)
+}
+
+func TestRefreshLegacySession(t *testing.T) {
+	app := newTestApp(t)
+	app.signup("alice", "alice@example.com", "password")
+	user, err := app.appC.DBStore.UserRepository.FindByEmail(context.Background(), "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a session saved before the refresh tokens had a purpose and the sessions a family
+	refreshToken, payload, err := app.appC.TokenCreator.Create(context.Background(), user.ID, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = app.appC.DBStore.SessionRepository.Create(context.Background(), &models.Session{
+		ID:                  payload.Id.String(),
+		UserID:              user.ID,
+		RefreshToken:        refreshToken,
+		RefreshTokenExpires: payload.ExpiredAt,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next := call[controllers.RefreshTokenResponse](app, http.MethodPost, "/refresh-token", "", controllers.RefreshTokenRequest{RefreshToken: refreshToken}, http.StatusOK)
+	call[controllers.RefreshTokenResponse](app, http.MethodPost, "/refresh-token", "", controllers.RefreshTokenRequest{RefreshToken: next.RefreshToken}, http.StatusOK)
+
+	// the access tokens have no purpose either, but no session
+	rec := app.do(http.MethodPost, "/refresh-token", "", controllers.RefreshTokenRequest{RefreshToken: next.AccessToken})
+	expectProblem(app, rec, http.StatusUnauthorized, "invalid_token")
+}
+
+// the sessions repository failing to save or to rotate the sessions
+type failingSessions struct {
+	repository.SessionRepository
+	create, rotate bool
+}
+
+func (f failingSessions) Create(ctx context.Context, session *models.Session) error {
+	if f.create {
+		return errors.New("connection lost")
+	}
+	return f.SessionRepository.Create(ctx, session)
+}
+
+func (f failingSessions) MarkRotated(ctx context.Context, id string, replacedBy string) error {
+	if f.rotate {
+		return errors.New("connection lost")
+	}
+	return f.SessionRepository.MarkRotated(ctx, id, replacedBy)
+}
+
+func TestRefreshFailedRotationKeepsTheSession(t *testing.T) {
+	for name, failing := range map[string]failingSessions{
+		"save":   {create: true},
+		"rotate": {rotate: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			app := newTestApp(t)
+			app.signup("alice", "alice@example.com", "password")
+			tokens := app.login("alice@example.com", "password")
+
+			sessions := app.appC.DBStore.SessionRepository
+			failing.SessionRepository = sessions
+			app.appC.DBStore.SessionRepository = failing
+			rec := app.do(http.MethodPost, "/refresh-token", "", controllers.RefreshTokenRequest{RefreshToken: tokens.RefreshToken})
+			if rec.Code != http.StatusInternalServerError {
+				t.Fatalf("got %d, want 500: %s", rec.Code, rec.Body.String())
+			}
+			app.appC.DBStore.SessionRepository = sessions
+
+			// the failed rotation didn't retire the token, it still works and isn't seen as reused
+			next := call[controllers.RefreshTokenResponse](app, http.MethodPost, "/refresh-token", "", controllers.RefreshTokenRequest{RefreshToken: tokens.RefreshToken}, http.StatusOK)
+			active := call[[]controllers.SessionResponse](app, http.MethodGet, "/me/sessions", next.AccessToken, nil, http.StatusOK)
+			if len(active) != 1 {
+				t.Fatalf("got %d active sessions, want 1", len(active))
+			}
+		})
+	}
+}
+
+func TestRefreshOfDisabledUser(t *testing.T) {
+	app := newTestApp(t)
+	app.signup("alice", "alice@example.com", "password")
+	tokens := app.login("alice@example.com", "password")
+
+	// the user is disabled behind the back of the sessions
+	user, err := app.appC.DBStore.UserRepository.FindByEmail(context.Background(), "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.appC.DBStore.UserRepository.SetDisabled(context.Background(), user.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := app.do(http.MethodPost, "/refresh-token", "", controllers.RefreshTokenRequest{RefreshToken: tokens.RefreshToken})
+	expectProblem(app, rec, http.StatusForbidden, "account_disabled")
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ayehia0/org/pkg/database/mongodb"
+	"github.com/ayehia0/org/pkg/database/redis"
+	"github.com/ayehia0/org/pkg/utils"
+)
+
+// the storage drivers, selected by the type in the database config
+const (
+	TypeMongoDB  = "mongodb"  // the documents are stored in mongodb and the sessions are cached in redis
+	TypePostgres = "postgres" // the data is stored in postgres and the sessions are cached in redis
+	TypeMemory   = "memory"   // everything lives in the process memory, no external service is needed
+)
+
+// a check of a dependency of a driver, it returns an error when the dependency can't be reached
+type HealthCheck func(ctx context.Context) error
+
+// a driver gives access to the repositories of a storage backend
+type Driver interface {
+	DBStore() *mongodb.DBStore
+	RedisStore() *redis.RedisStore
+	Migrate(ctx context.Context) (int, error) // apply the pending migrations, returns how many were applied
+	Close(ctx context.Context) error          // disconnect from the databases, the stores can't be used anymore
+	HealthChecks() map[string]HealthCheck     // the checks of the dependencies by name, used by the readiness probe
+}
+
+// open the driver chosen by the database config, mongodb is the default
+func Open(dbConfig *utils.DatabaseConfig, redisConfig *utils.RedisConfig) (Driver, error) {
+	switch dbConfig.Type {
+	case TypeMongoDB, "":
+		return openMongoDB(dbConfig, redisConfig)
+	case TypePostgres:
+		return openPostgres(dbConfig, redisConfig)
+	case TypeMemory:
+		return openMemory(), nil
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", dbConfig.Type)
+	}
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"context"
+
+	"github.com/ayehia0/org/pkg/database/memory"
+	"github.com/ayehia0/org/pkg/database/mongodb"
+	"github.com/ayehia0/org/pkg/database/redis"
+)
+
+// the memory driver, the data is lost when the process stops
+type memoryDriver struct {
+	dbStore    *mongodb.DBStore
+	redisStore *redis.RedisStore
+}
+
+func openMemory() Driver {
+	store := memory.NewStore()
+	return &memoryDriver{
+		dbStore:    store.DBStore(),
+		redisStore: store.RedisStore(),
+	}
+}
+
+func (d *memoryDriver) DBStore() *mongodb.DBStore {
+	return d.dbStore
+}
+
+func (d *memoryDriver) RedisStore() *redis.RedisStore {
+	return d.redisStore
+}
+
+// the memory driver starts empty, there is nothing to migrate
+func (d *memoryDriver) Migrate(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (d *memoryDriver) Close(ctx context.Context) error {
+	return nil
+}
+
+// the memory driver has no dependency to check
+func (d *memoryDriver) HealthChecks() map[string]HealthCheck {
+	return map[string]HealthCheck{}
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/metrics"
+)
+
+// a cached session, it expires with its refresh token like the redis key
+type cacheRecord struct {
+	session   models.Session
+	expiresAt time.Time
+}
+
+// the session cache replacing redis, the sessions are keyed by their refresh token
+type cacheRepository struct {
+	store *Store
+}
+
+func (r *cacheRepository) CreateSession(ctx context.Context, session *models.Session) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.cache[session.RefreshToken] = &cacheRecord{session: *session, expiresAt: session.RefreshTokenExpires}
+	return nil
+}
+
+func (r *cacheRepository) GetSessionByID(ctx context.Context, id string) (*models.Session, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record, ok := r.store.cache[id]
+	if !ok {
+		metrics.ObserveSessionCache(false)
+		return nil, nil
+	}
+	// the expired entries are dropped when they are read
+	if !record.expiresAt.After(time.Now()) {
+		delete(r.store.cache, id)
+		metrics.ObserveSessionCache(false)
+		return nil, nil
+	}
+
+	metrics.ObserveSessionCache(true)
+	session := record.session
+	return &session, nil
+}
+
+func (r *cacheRepository) DeleteSession(ctx context.Context, id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.cache, id)
+	return nil
+}
@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/database/mongodb/repository"
+)
+
+// a stored invitation
+type invitationRecord struct {
+	invitation models.Invitation
+}
+
+// the invitation repository struct
+type invitationRepository struct {
+	store *Store
+}
+
+// the function to create a new invitation
+func (r *invitationRepository) Create(ctx context.Context, invitation *models.Invitation) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record := &invitationRecord{invitation: *invitation}
+	record.invitation.ID = newID()
+	r.store.invitations[record.invitation.ID] = record
+	return record.invitation.ID, nil
+}
+
+// the function to find an invitation by id
+func (r *invitationRepository) FindByID(ctx context.Context, id string) (*models.Invitation, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	record, ok := r.store.invitations[id]
+	if !ok {
+		return nil, repository.ErrInvitationNotFound
+	}
+	invitation := record.invitation
+	return &invitation, nil
+}
+
+// the function to find all the pending invitations of an email
+func (r *invitationRepository) FindPendingByEmail(ctx context.Context, email string) ([]models.Invitation, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	invitations := []models.Invitation{}
+	for _, record := range r.store.invitations {
+		if record.invitation.Email == email && record.invitation.Status == models.InvitationStatusPending {
+			invitations = append(invitations, record.invitation)
+		}
+	}
+	return invitations, nil
+}
+
+// the function to check if the email already has a pending invitation to the organization
+func (r *invitationRepository) HasPending(ctx context.Context, orgID string, email string) (bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	now := time.Now()
+	for _, record := range r.store.invitations {
+		invitation := &record.invitation
+		if invitation.OrganizationID == orgID && invitation.Email == email &&
+			invitation.Status == models.InvitationStatusPending && invitation.ExpiresAt.After(now) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// the function to change the status of an invitation
+func (r *invitationRepository) UpdateStatus(ctx context.Context, id string, status string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record, ok := r.store.invitations[id]
+	if !ok {
+		return repository.ErrInvitationNotFound
+	}
+	record.invitation.Status = status
+	record.invitation.UpdatedAt = time.Now()
+	return nil
+}
+
+// the function to link the pending invitations sent to an email before signing up to the user
+func (r *invitationRepository) AttachUser(ctx context.Context, email string, userID string) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var count int64
+	for _, record := range r.store.invitations {
+		if record.invitation.Email == email && record.invitation.Status == models.InvitationStatusPending {
+			record.invitation.UserID = userID
+			record.invitation.UpdatedAt = time.Now()
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+)
+
+func TestInvitationAttachUser(t *testing.T) {
+	ctx := context.Background()
+	invitations := NewStore().DBStore().InvitationRepository
+
+	pendingID, _ := invitations.Create(ctx, &models.Invitation{OrganizationID: "org", Email: "bob@example.com", Status: models.InvitationStatusPending})
+	acceptedID, _ := invitations.Create(ctx, &models.Invitation{OrganizationID: "other", Email: "bob@example.com", Status: models.InvitationStatusAccepted})
+	invitations.Create(ctx, &models.Invitation{OrganizationID: "org", Email: "eve@example.com", Status: models.InvitationStatusPending})
+
+	// only the pending invitations of the email are attached
+	count, err := invitations.AttachUser(ctx, "bob@example.com", "bob")
+	if err != nil || count != 1 {
+		t.Fatalf("got %d, %v, want 1 attached invitation", count, err)
+	}
+
+	pending, _ := invitations.FindByID(ctx, pendingID)
+	if pending.UserID != "bob" {
+		t.Fatalf("got user %q, want bob", pending.UserID)
+	}
+	accepted, _ := invitations.FindByID(ctx, acceptedID)
+	if accepted.UserID != "" {
+		t.Fatalf("the accepted invitation has been attached to %q", accepted.UserID)
+	}
+
+	found, _ := invitations.FindPendingByEmail(ctx, "bob@example.com")
+	if len(found) != 1 || found[0].ID != pendingID {
+		t.Fatalf("unexpected pending invitations: %+v", found)
+	}
+}
@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+// the failed attempts of a key, they are forgotten once the window is over
+type failuresRecord struct {
+	count     int64
+	expiresAt time.Time
+}
+
+// the account lockouts replacing redis
+type lockoutRepository struct {
+	store *Store
+}
+
+func (r *lockoutRepository) RecordFailure(ctx context.Context, key string, window time.Duration) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	record, ok := r.store.failures[key]
+	// the window starts with the first failure
+	if !ok || !record.expiresAt.After(now) {
+		record = &failuresRecord{expiresAt: now.Add(window)}
+		r.store.failures[key] = record
+	}
+	record.count++
+	return record.count, nil
+}
+
+func (r *lockoutRepository) Lock(ctx context.Context, key string, duration time.Duration) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.locks[key] = time.Now().Add(duration)
+	delete(r.store.failures, key)
+	return nil
+}
+
+func (r *lockoutRepository) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	until, ok := r.store.locks[key]
+	if !ok {
+		return 0, nil
+	}
+	remaining := time.Until(until)
+	if remaining <= 0 {
+		delete(r.store.locks, key)
+		return 0, nil
+	}
+	return remaining, nil
+}
+
+func (r *lockoutRepository) Reset(ctx context.Context, key string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.failures, key)
+	return nil
+}
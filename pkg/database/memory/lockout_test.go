@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLockout(t *testing.T) {
+	ctx := context.Background()
+	lockout := NewStore().RedisStore().LockoutRepository
+
+	for want := int64(1); want <= 3; want++ {
+		count, err := lockout.RecordFailure(ctx, "alice", time.Minute)
+		if err != nil || count != want {
+			t.Fatalf("got %d, %v, want %d failures", count, err, want)
+		}
+	}
+
+	if err := lockout.Lock(ctx, "alice", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if remaining, _ := lockout.LockedFor(ctx, "alice"); remaining <= 0 || remaining > time.Minute {
+		t.Fatalf("got %s remaining, want up to a minute", remaining)
+	}
+	if remaining, _ := lockout.LockedFor(ctx, "bob"); remaining != 0 {
+		t.Fatalf("bob is locked for %s", remaining)
+	}
+
+	// the failures start over after the lock
+	if count, _ := lockout.RecordFailure(ctx, "alice", time.Minute); count != 1 {
+		t.Fatalf("got %d failures after the lock, want 1", count)
+	}
+}
+
+func TestLockoutWindow(t *testing.T) {
+	ctx := context.Background()
+	lockout := NewStore().RedisStore().LockoutRepository
+
+	lockout.RecordFailure(ctx, "alice", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if count, _ := lockout.RecordFailure(ctx, "alice", time.Minute); count != 1 {
+		t.Fatalf("got %d failures, the expired window is still counted", count)
+	}
+
+	lockout.Lock(ctx, "alice", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if remaining, _ := lockout.LockedFor(ctx, "alice"); remaining != 0 {
+		t.Fatalf("the expired lock still holds for %s", remaining)
+	}
+}
@@ -0,0 +1,346 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/database/mongodb/repository"
+)
+
+// a stored organization
+type orgRecord struct {
+	org models.Organization
+}
+
+// the organization repository struct
+type organizationRepository struct {
+	store *Store
+}
+
+// copy the organization with its own members and pending transfer
+func cloneOrganization(org *models.Organization) *models.Organization {
+	c := *org
+	c.Members = append([]models.Member(nil), org.Members...)
+	if org.PendingTransfer != nil {
+		transfer := *org.PendingTransfer
+		c.PendingTransfer = &transfer
+	}
+	return &c
+}
+
+// check if a user created or is a member of the organization
+func isMember(org *models.Organization, userID string) bool {
+	if org.Creator == userID {
+		return true
+	}
+	for _, member := range org.Members {
+		if member.ID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// the function to create a new organization
+func (r *organizationRepository) Create(ctx context.Context, org *models.Organization) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record := &orgRecord{org: *cloneOrganization(org)}
+	record.org.ID = newID()
+	r.store.organizations[record.org.ID] = record
+	return record.org.ID, nil
+}
+
+// the function to find an organization by id
+func (r *organizationRepository) FindByID(ctx context.Context, id string) (*models.Organization, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	record, ok := r.store.organizations[id]
+	if !ok {
+		return nil, repository.ErrOrganizationNotFound
+	}
+	return cloneOrganization(&record.org), nil
+}
+
+// apply a change to an organization, the change can refuse it by returning an error
+func (r *organizationRepository) update(id string, change func(org *models.Organization) error) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record, ok := r.store.organizations[id]
+	if !ok {
+		return repository.ErrOrganizationNotFound
+	}
+
+	// the change is made on a copy so a refused change leaves the organization untouched
+	org := cloneOrganization(&record.org)
+	if err := change(org); err != nil {
+		return err
+	}
+	record.org = *org
+	return nil
+}
+
+// the function to update an organization
+// update only the given fields
+func (r *organizationRepository) Update(ctx context.Context, org *models.Organization) (*models.Organization, error) {
+	err := r.update(org.ID, func(stored *models.Organization) error {
+		stored.Name = org.Name
+		stored.Desc = org.Desc
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// the function to delete an organization
+func (r *organizationRepository) Delete(ctx context.Context, id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.organizations[id]; !ok {
+		return repository.ErrOrganizationNotFound
+	}
+	delete(r.store.organizations, id)
+	return nil
+}
+
+// the function to add a member to an organization
+func (r *organizationRepository) AddMember(ctx context.Context, orgID string, member *models.Member) error {
+	return r.update(orgID, func(org *models.Organization) error {
+		if memberIndex(org, member.ID) >= 0 {
+			return repository.ErrMemberExists
+		}
+		org.Members = append(org.Members, *member)
+		return nil
+	})
+}
+
+// collect the organizations matching the filter
+func (r *organizationRepository) filter(match func(org *models.Organization) bool) []models.Organization {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	orgs := []models.Organization{}
+	for _, record := range r.store.organizations {
+		if match(&record.org) {
+			orgs = append(orgs, *cloneOrganization(&record.org))
+		}
+	}
+	return orgs
+}
+
+func (r *organizationRepository) FindAll(ctx context.Context) ([]models.Organization, error) {
+	return r.filter(func(org *models.Organization) bool { return true }), nil
+}
+
+func (r *organizationRepository) IsUserInOrganization(ctx context.Context, orgID string, email string) (bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	record, ok := r.store.organizations[orgID]
+	if !ok {
+		return false, nil
+	}
+	for _, member := range record.org.Members {
+		if member.Email == email {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// the function to find the organizations a user created or is a member of
+func (r *organizationRepository) FindByMember(ctx context.Context, userID string) ([]models.Organization, error) {
+	return r.filter(func(org *models.Organization) bool {
+		return isMember(org, userID)
+	}), nil
+}
+
+// the function to list the organizations of a member page by page
+// the organizations are sorted the same way as in mongodb: by id (creation time) or by name then id
+func (r *organizationRepository) ListByMember(ctx context.Context, opts repository.OrganizationListOptions) ([]models.Organization, string, error) {
+	var cursor *repository.ListCursor
+	if opts.Cursor != "" {
+		var err error
+		if cursor, err = repository.DecodeCursor(opts.Cursor); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// compare two organizations by the sort key, the id breaks the ties
+	compare := func(name, id string, other *models.Organization) int {
+		if opts.SortBy == repository.SortByName {
+			if c := strings.Compare(name, other.Name); c != 0 {
+				return c
+			}
+		}
+		return strings.Compare(id, other.ID)
+	}
+
+	search := strings.ToLower(opts.Name)
+	orgs := r.filter(func(org *models.Organization) bool {
+		if !isMember(org, opts.MemberID) {
+			return false
+		}
+		if search != "" && !strings.Contains(strings.ToLower(org.Name), search) {
+			return false
+		}
+		// continue right after the last organization of the previous page
+		if cursor != nil {
+			c := compare(cursor.Name, cursor.ID, org)
+			if opts.Descending {
+				return c > 0
+			}
+			return c < 0
+		}
+		return true
+	})
+
+	sort.Slice(orgs, func(i, j int) bool {
+		c := compare(orgs[i].Name, orgs[i].ID, &orgs[j])
+		if opts.Descending {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	if len(orgs) <= opts.Limit {
+		return orgs, "", nil
+	}
+
+	orgs = orgs[:opts.Limit]
+	last := orgs[len(orgs)-1]
+	next := repository.ListCursor{ID: last.ID}
+	if opts.SortBy == repository.SortByName {
+		next.Name = last.Name
+	}
+	return orgs, repository.EncodeCursor(next), nil
+}
+
+// find the index of a member in an organization
+func memberIndex(org *models.Organization, userID string) int {
+	for i, member := range org.Members {
+		if member.ID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+// the function to change the access level of a member
+func (r *organizationRepository) UpdateMemberAccessLevel(ctx context.Context, orgID string, userID string, accessLevel string) error {
+	return r.update(orgID, func(org *models.Organization) error {
+		i := memberIndex(org, userID)
+		if i < 0 {
+			return repository.ErrMemberNotFound
+		}
+		org.Members[i].AccessLevel = accessLevel
+		return nil
+	})
+}
+
+// the function to change the name of a user in every organization he is a member of
+func (r *organizationRepository) UpdateMemberName(ctx context.Context, userID string, name string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, record := range r.store.organizations {
+		if i := memberIndex(&record.org, userID); i >= 0 {
+			record.org.Members[i].Name = name
+		}
+	}
+	return nil
+}
+
+// the function to remove a member from an organization
+func (r *organizationRepository) RemoveMember(ctx context.Context, orgID string, userID string) error {
+	return r.update(orgID, func(org *models.Organization) error {
+		i := memberIndex(org, userID)
+		if i < 0 {
+			return repository.ErrMemberNotFound
+		}
+		org.Members = append(org.Members[:i], org.Members[i+1:]...)
+		return nil
+	})
+}
+
+// the function to remove a user from all the organizations
+func (r *organizationRepository) RemoveMemberFromAll(ctx context.Context, userID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, record := range r.store.organizations {
+		if i := memberIndex(&record.org, userID); i >= 0 {
+			record.org.Members = append(record.org.Members[:i], record.org.Members[i+1:]...)
+		}
+	}
+	return nil
+}
+
+// the function to change the creator of an organization
+func (r *organizationRepository) SetCreator(ctx context.Context, orgID string, userID string) error {
+	return r.update(orgID, func(org *models.Organization) error {
+		org.Creator = userID
+		return nil
+	})
+}
+
+// the function to save a pending ownership transfer, it replaces the previous one if any
+func (r *organizationRepository) ProposeTransfer(ctx context.Context, orgID string, transfer *models.OwnershipTransfer) error {
+	return r.update(orgID, func(org *models.Organization) error {
+		pending := *transfer
+		org.PendingTransfer = &pending
+		return nil
+	})
+}
+
+// the function to drop the pending ownership transfer
+func (r *organizationRepository) ClearTransfer(ctx context.Context, orgID string) error {
+	err := r.update(orgID, func(org *models.Organization) error {
+		org.PendingTransfer = nil
+		return nil
+	})
+	// like mongodb, clearing the transfer of a missing organization isn't an error
+	if errors.Is(err, repository.ErrOrganizationNotFound) {
+		return nil
+	}
+	return err
+}
+
+// the function to complete the ownership transfer, the whole change is applied under the lock
+// so the organization is never seen half transferred, it only happens if the transfer is still pending
+func (r *organizationRepository) CompleteTransfer(ctx context.Context, orgID string, from string, to string) error {
+	err := r.update(orgID, func(org *models.Organization) error {
+		transfer := org.PendingTransfer
+		if transfer == nil || transfer.From != from || transfer.To != to || memberIndex(org, to) < 0 {
+			return repository.ErrTransferNotFound
+		}
+		// the proposer must still be an owner, the creator is always one
+		if i := memberIndex(org, from); org.Creator != from && (i < 0 || org.Members[i].AccessLevel != models.AccessLevelOwner) {
+			return repository.ErrTransferNotFound
+		}
+		org.Creator = to
+		for i := range org.Members {
+			switch org.Members[i].ID {
+			case to:
+				org.Members[i].AccessLevel = models.AccessLevelOwner
+			case from:
+				org.Members[i].AccessLevel = models.AccessLevelAdmin
+			}
+		}
+		org.PendingTransfer = nil
+		return nil
+	})
+	if errors.Is(err, repository.ErrOrganizationNotFound) {
+		return repository.ErrTransferNotFound
+	}
+	return err
+}
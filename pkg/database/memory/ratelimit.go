@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+// the hits of a rate limit key, the oldest first
+type hitsRecord struct {
+	hits []time.Time
+}
+
+// the sliding window rate limits replacing redis
+type rateLimitRepository struct {
+	store *Store
+}
+
+func (r *rateLimitRepository) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	record, ok := r.store.hits[key]
+	if !ok {
+		record = &hitsRecord{}
+		r.store.hits[key] = record
+	}
+
+	// drop the hits that are out of the window
+	start := 0
+	for start < len(record.hits) && !record.hits[start].After(now.Add(-window)) {
+		start++
+	}
+	record.hits = record.hits[start:]
+
+	if len(record.hits) < limit {
+		record.hits = append(record.hits, now)
+		return true, 0, nil
+	}
+	return false, record.hits[0].Add(window).Sub(now), nil
+}
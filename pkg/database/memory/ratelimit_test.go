@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	ctx := context.Background()
+	limits := NewStore().RedisStore().RateLimitRepository
+
+	for i := 0; i < 3; i++ {
+		if allowed, _, _ := limits.Allow(ctx, "ip", 3, time.Minute); !allowed {
+			t.Fatalf("the hit %d has been refused", i+1)
+		}
+	}
+
+	allowed, retryAfter, err := limits.Allow(ctx, "ip", 3, time.Minute)
+	if err != nil || allowed {
+		t.Fatalf("got %t, %v, want the hit to be refused", allowed, err)
+	}
+	if retryAfter <= 0 || retryAfter > time.Minute {
+		t.Fatalf("got retry after %s, want up to a minute", retryAfter)
+	}
+
+	// the keys are limited separately
+	if allowed, _, _ := limits.Allow(ctx, "other", 3, time.Minute); !allowed {
+		t.Fatal("another key has been refused")
+	}
+}
+
+func TestRateLimitSlidingWindow(t *testing.T) {
+	ctx := context.Background()
+	limits := NewStore().RedisStore().RateLimitRepository
+
+	limits.Allow(ctx, "ip", 1, 10*time.Millisecond)
+	if allowed, _, _ := limits.Allow(ctx, "ip", 1, 10*time.Millisecond); allowed {
+		t.Fatal("the second hit has been allowed")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if allowed, _, _ := limits.Allow(ctx, "ip", 1, 10*time.Millisecond); !allowed {
+		t.Fatal("the hit after the window has been refused")
+	}
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+// a revoked token, the entry lives as long as the token it revokes
+type revokedRecord struct {
+	expiresAt time.Time
+}
+
+// the denylist of the revoked tokens replacing redis
+type revocationRepository struct {
+	store *Store
+}
+
+func (r *revocationRepository) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	// the token has already expired, nothing to revoke
+	if !expiresAt.After(time.Now()) {
+		return nil
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.revoked[tokenID] = &revokedRecord{expiresAt: expiresAt}
+	return nil
+}
+
+func (r *revocationRepository) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record, ok := r.store.revoked[tokenID]
+	if !ok {
+		return false, nil
+	}
+	if !record.expiresAt.After(time.Now()) {
+		delete(r.store.revoked, tokenID)
+		return false, nil
+	}
+	return true, nil
+}
+
+func (r *revocationRepository) Claim(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error) {
+	now := time.Now()
+	if !expiresAt.After(now) {
+		return false, nil
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if record, ok := r.store.revoked[tokenID]; ok && record.expiresAt.After(now) {
+		return false, nil
+	}
+	r.store.revoked[tokenID] = &revokedRecord{expiresAt: expiresAt}
+	return true, nil
+}
@@ -0,0 +1,28 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRevocationClaim(t *testing.T) {
+	ctx := context.Background()
+	revocations := NewStore().RedisStore().RevocationRepository
+
+	// only the first claim wins, the token is revoked from then on
+	if claimed, err := revocations.Claim(ctx, "token", time.Now().Add(time.Minute)); err != nil || !claimed {
+		t.Fatalf("got %t, %v, want the first claim to win", claimed, err)
+	}
+	if claimed, _ := revocations.Claim(ctx, "token", time.Now().Add(time.Minute)); claimed {
+		t.Fatal("the token has been claimed twice")
+	}
+	if revoked, _ := revocations.IsRevoked(ctx, "token"); !revoked {
+		t.Fatal("the claimed token isn't revoked")
+	}
+
+	// the expired tokens can't be claimed
+	if claimed, _ := revocations.Claim(ctx, "expired", time.Now().Add(-time.Minute)); claimed {
+		t.Fatal("an expired token has been claimed")
+	}
+}
@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/database/mongodb/repository"
+)
+
+// a stored session
+type sessionRecord struct {
+	session models.Session
+}
+
+// the session repository struct
+type sessionRepository struct {
+	store *Store
+}
+
+// the function to create a new session
+func (r *sessionRepository) Create(ctx context.Context, session *models.Session) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if session.ID == "" {
+		session.ID = newID()
+	}
+	if _, ok := r.store.sessions[session.ID]; ok {
+		return repository.ErrSessionExists
+	}
+	r.store.sessions[session.ID] = &sessionRecord{session: *session}
+	return nil
+}
+
+// the function to find a session by id
+func (r *sessionRepository) FindByID(ctx context.Context, id string) (*models.Session, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	record, ok := r.store.sessions[id]
+	if !ok {
+		return nil, repository.ErrSessionNotFound
+	}
+	session := record.session
+	return &session, nil
+}
+
+// collect the sessions matching the filter
+func (r *sessionRepository) filter(match func(session *models.Session) bool) []models.Session {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	sessions := []models.Session{}
+	for _, record := range r.store.sessions {
+		if match(&record.session) {
+			sessions = append(sessions, record.session)
+		}
+	}
+	return sessions
+}
+
+// the function to find the active sessions of a user, the rotated ones are only kept to detect reuse
+func (r *sessionRepository) FindByUserID(ctx context.Context, userID string) ([]models.Session, error) {
+	return r.filter(func(session *models.Session) bool {
+		return session.UserID == userID && session.ReplacedBy == ""
+	}), nil
+}
+
+// the function to find all the sessions of a user, the rotated ones included
+func (r *sessionRepository) FindAllByUserID(ctx context.Context, userID string) ([]models.Session, error) {
+	return r.filter(func(session *models.Session) bool {
+		return session.UserID == userID
+	}), nil
+}
+
+// the function to find the session of an access token
+func (r *sessionRepository) FindByAccessTokenID(ctx context.Context, tokenID string) (*models.Session, error) {
+	sessions := r.filter(func(session *models.Session) bool {
+		return session.AccessTokenID == tokenID
+	})
+	if len(sessions) == 0 {
+		return nil, repository.ErrSessionNotFound
+	}
+	return &sessions[0], nil
+}
+
+// the function to find all the sessions that belong to the same token family
+func (r *sessionRepository) FindByFamilyID(ctx context.Context, familyID string) ([]models.Session, error) {
+	return r.filter(func(session *models.Session) bool {
+		return session.FamilyID == familyID
+	}), nil
+}
+
+// the function to mark a session as replaced by a newer one
+// the check and the change happen under the same lock, so two concurrent refreshes can't both win
+func (r *sessionRepository) MarkRotated(ctx context.Context, id string, replacedBy string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record, ok := r.store.sessions[id]
+	if !ok || record.session.ReplacedBy != "" {
+		return repository.ErrSessionRotated
+	}
+	record.session.ReplacedBy = replacedBy
+	return nil
+}
+
+// the function to record the last use of a session, the sessions used less than every ago are skipped
+func (r *sessionRepository) Touch(ctx context.Context, tokenID string, usedAt time.Time, every time.Duration) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, record := range r.store.sessions {
+		if record.session.AccessTokenID == tokenID && record.session.LastUsedAt.Before(usedAt.Add(-every)) {
+			record.session.LastUsedAt = usedAt
+		}
+	}
+	return nil
+}
+
+// the function to delete a session
+func (r *sessionRepository) Delete(ctx context.Context, id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.sessions, id)
+	return nil
+}
+
+// delete the sessions matching the filter
+func (r *sessionRepository) deleteWhere(match func(session *models.Session) bool) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, record := range r.store.sessions {
+		if match(&record.session) {
+			delete(r.store.sessions, id)
+		}
+	}
+}
+
+// the function to delete all the sessions of a token family
+func (r *sessionRepository) DeleteByFamilyID(ctx context.Context, familyID string) error {
+	r.deleteWhere(func(session *models.Session) bool {
+		return session.FamilyID == familyID
+	})
+	return nil
+}
+
+// the function to delete all the sessions of a user
+func (r *sessionRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	r.deleteWhere(func(session *models.Session) bool {
+		return session.UserID == userID
+	})
+	return nil
+}
+
+// the function to delete the sessions whose refresh token has expired
+func (r *sessionRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var count int64
+	now := time.Now()
+	for id, record := range r.store.sessions {
+		if record.session.RefreshTokenExpires.Before(now) {
+			delete(r.store.sessions, id)
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/database/mongodb/repository"
+)
+
+func TestSessionRotation(t *testing.T) {
+	ctx := context.Background()
+	sessions := NewStore().DBStore().SessionRepository
+
+	first := &models.Session{UserID: "user", FamilyID: "family", RefreshTokenExpires: time.Now().Add(time.Hour)}
+	if err := sessions.Create(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	next := &models.Session{UserID: "user", FamilyID: "family", RefreshTokenExpires: time.Now().Add(time.Hour)}
+	if err := sessions.Create(ctx, next); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sessions.MarkRotated(ctx, first.ID, next.ID); err != nil {
+		t.Fatal(err)
+	}
+	// a session is only rotated once
+	if err := sessions.MarkRotated(ctx, first.ID, "other"); !errors.Is(err, repository.ErrSessionRotated) {
+		t.Fatalf("got %v, want %v", err, repository.ErrSessionRotated)
+	}
+
+	// the rotated session is kept for the reuse detection but isn't active anymore
+	active, _ := sessions.FindByUserID(ctx, "user")
+	if len(active) != 1 || active[0].ID != next.ID {
+		t.Fatalf("unexpected active sessions: %+v", active)
+	}
+	family, _ := sessions.FindByFamilyID(ctx, "family")
+	if len(family) != 2 {
+		t.Fatalf("got %d sessions in the family, want 2", len(family))
+	}
+
+	if err := sessions.DeleteByFamilyID(ctx, "family"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions.FindByID(ctx, next.ID); !errors.Is(err, repository.ErrSessionNotFound) {
+		t.Fatalf("got %v, want %v", err, repository.ErrSessionNotFound)
+	}
+}
+
+func TestSessionConcurrentRotation(t *testing.T) {
+	ctx := context.Background()
+	sessions := NewStore().DBStore().SessionRepository
+
+	session := &models.Session{UserID: "user", FamilyID: "family"}
+	if err := sessions.Create(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	won := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if sessions.MarkRotated(ctx, session.ID, newID()) == nil {
+				mu.Lock()
+				won++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if won != 1 {
+		t.Fatalf("%d refreshes won the rotation, want 1", won)
+	}
+}
+
+func TestSessionDeleteExpired(t *testing.T) {
+	ctx := context.Background()
+	sessions := NewStore().DBStore().SessionRepository
+
+	expired := &models.Session{UserID: "user", RefreshTokenExpires: time.Now().Add(-time.Minute)}
+	valid := &models.Session{UserID: "user", RefreshTokenExpires: time.Now().Add(time.Hour)}
+	for _, session := range []*models.Session{expired, valid} {
+		if err := sessions.Create(ctx, session); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count, err := sessions.DeleteExpired(ctx)
+	if err != nil || count != 1 {
+		t.Fatalf("got %d, %v, want 1 deleted session", count, err)
+	}
+	if _, err := sessions.FindByID(ctx, valid.ID); err != nil {
+		t.Fatalf("the valid session is gone: %v", err)
+	}
+}
+
+func TestSessionTouch(t *testing.T) {
+	ctx := context.Background()
+	sessions := NewStore().DBStore().SessionRepository
+
+	login := time.Now().Add(-time.Hour)
+	session := &models.Session{UserID: "user", AccessTokenID: "token", LastUsedAt: login}
+	if err := sessions.Create(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+
+	used := time.Now()
+	if err := sessions.Touch(ctx, "token", used, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	// within the interval the last use isn't written again
+	if err := sessions.Touch(ctx, "token", used.Add(time.Second), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	found, _ := sessions.FindByID(ctx, session.ID)
+	if !found.LastUsedAt.Equal(used) {
+		t.Fatalf("got last use %s, want %s", found.LastUsedAt, used)
+	}
+}
@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ayehia0/org/pkg/database/mongodb"
+	"github.com/ayehia0/org/pkg/database/redis"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// the memory package keeps everything in the process memory, nothing is persisted between restarts
+// it implements the same repositories as mongodb and redis so it can replace them for the development and the tests
+
+// the shared state of the memory driver, a single lock guards all the collections
+type Store struct {
+	mu            sync.RWMutex
+	users         map[string]*userRecord
+	sessions      map[string]*sessionRecord
+	organizations map[string]*orgRecord
+	invitations   map[string]*invitationRecord
+	cache         map[string]*cacheRecord
+	revoked       map[string]*revokedRecord
+	hits          map[string]*hitsRecord
+	failures      map[string]*failuresRecord
+	locks         map[string]time.Time
+}
+
+// create a new empty store
+func NewStore() *Store {
+	return &Store{
+		users:         map[string]*userRecord{},
+		sessions:      map[string]*sessionRecord{},
+		organizations: map[string]*orgRecord{},
+		invitations:   map[string]*invitationRecord{},
+		cache:         map[string]*cacheRecord{},
+		revoked:       map[string]*revokedRecord{},
+		hits:          map[string]*hitsRecord{},
+		failures:      map[string]*failuresRecord{},
+		locks:         map[string]time.Time{},
+	}
+}
+
+// the repositories that replace the mongodb ones
+func (s *Store) DBStore() *mongodb.DBStore {
+	return &mongodb.DBStore{
+		UserRepository:         &userRepository{store: s},
+		SessionRepository:      &sessionRepository{store: s},
+		OrganizationRepository: &organizationRepository{store: s},
+		InvitationRepository:   &invitationRepository{store: s},
+	}
+}
+
+// the repositories that replace the redis ones
+func (s *Store) RedisStore() *redis.RedisStore {
+	return &redis.RedisStore{
+		SessionRepository:    &cacheRepository{store: s},
+		RevocationRepository: &revocationRepository{store: s},
+		RateLimitRepository:  &rateLimitRepository{store: s},
+		LockoutRepository:    &lockoutRepository{store: s},
+	}
+}
+
+// the ids look like the mongodb ones so they are accepted everywhere an object id is expected
+func newID() string {
+	return primitive.NewObjectID().Hex()
+}
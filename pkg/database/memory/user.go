@@ -0,0 +1,162 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/database/mongodb/repository"
+)
+
+// a stored user, the documents are copied in and out so the callers can't change the store
+type userRecord struct {
+	user models.User
+}
+
+// the user repository struct
+type userRepository struct {
+	store *Store
+}
+
+// copy the user with its own recovery codes
+func cloneUser(user *models.User) *models.User {
+	c := *user
+	c.MFA.RecoveryCodes = append([]string(nil), user.MFA.RecoveryCodes...)
+	return &c
+}
+
+// the function to create a new user
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// the email is unique, checked under the same lock as the insert
+	for _, record := range r.store.users {
+		if record.user.Email == user.Email {
+			return repository.ErrEmailExists
+		}
+	}
+
+	user.ID = newID()
+	r.store.users[user.ID] = &userRecord{user: *cloneUser(user)}
+	return nil
+}
+
+// the function to find a user by email
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, record := range r.store.users {
+		if record.user.Email == email {
+			return cloneUser(&record.user), nil
+		}
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+// the function to find a user by id
+func (r *userRepository) FindByID(ctx context.Context, id string) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	record, ok := r.store.users[id]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+	return cloneUser(&record.user), nil
+}
+
+// apply a change to a user
+func (r *userRepository) update(id string, change func(user *models.User)) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record, ok := r.store.users[id]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	change(&record.user)
+	return nil
+}
+
+// the function to update the two factor authentication settings of a user
+func (r *userRepository) UpdateMFA(ctx context.Context, id string, mfa *models.MFA) error {
+	return r.update(id, func(user *models.User) {
+		user.MFA = *mfa
+		user.MFA.RecoveryCodes = append([]string(nil), mfa.RecoveryCodes...)
+	})
+}
+
+// the function to record the time step of an accepted code, only a later step than the recorded one is accepted
+func (r *userRepository) UseTOTPStep(ctx context.Context, id string, step int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record, ok := r.store.users[id]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	if record.user.LastTOTPStep >= step {
+		return repository.ErrCodeUsed
+	}
+	record.user.LastTOTPStep = step
+	return nil
+}
+
+// the function to remove a used recovery code, only a code that is still there is removed
+func (r *userRepository) UseRecoveryCode(ctx context.Context, id string, hashed string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record, ok := r.store.users[id]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	i := slices.Index(record.user.MFA.RecoveryCodes, hashed)
+	if i < 0 {
+		return repository.ErrCodeUsed
+	}
+	record.user.MFA.RecoveryCodes = slices.Delete(record.user.MFA.RecoveryCodes, i, i+1)
+	return nil
+}
+
+// the function to update the password of a user, the password must be already hashed
+func (r *userRepository) UpdatePassword(ctx context.Context, id string, password string) error {
+	return r.update(id, func(user *models.User) {
+		user.Password = password
+	})
+}
+
+// the function to mark the email of a user as verified
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id string) error {
+	return r.update(id, func(user *models.User) {
+		user.EmailVerified = true
+	})
+}
+
+// the function to change the name of a user
+func (r *userRepository) UpdateName(ctx context.Context, id string, name string) error {
+	return r.update(id, func(user *models.User) {
+		user.Name = name
+	})
+}
+
+// the function to disable or enable a user
+func (r *userRepository) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	return r.update(id, func(user *models.User) {
+		user.Disabled = disabled
+	})
+}
+
+// the function to delete a user
+func (r *userRepository) Delete(ctx context.Context, id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.users[id]; !ok {
+		return repository.ErrUserNotFound
+	}
+	delete(r.store.users, id)
+	return nil
+}
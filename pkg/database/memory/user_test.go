@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/database/mongodb/repository"
+)
+
+func TestUserUseTOTPStep(t *testing.T) {
+	ctx := context.Background()
+	users := NewStore().DBStore().UserRepository
+
+	user := &models.User{Email: "alice@example.com"}
+	if err := users.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := users.UseTOTPStep(ctx, user.ID, 100); err != nil {
+		t.Fatal(err)
+	}
+	// the same step and the earlier ones are refused
+	for _, step := range []int64{100, 99} {
+		if err := users.UseTOTPStep(ctx, user.ID, step); !errors.Is(err, repository.ErrCodeUsed) {
+			t.Fatalf("step %d: got %v, want %v", step, err, repository.ErrCodeUsed)
+		}
+	}
+	if err := users.UseTOTPStep(ctx, user.ID, 101); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUserUseRecoveryCode(t *testing.T) {
+	ctx := context.Background()
+	users := NewStore().DBStore().UserRepository
+
+	user := &models.User{Email: "alice@example.com", MFA: models.MFA{Enabled: true, RecoveryCodes: []string{"a", "b"}}}
+	if err := users.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := users.UseRecoveryCode(ctx, user.ID, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.UseRecoveryCode(ctx, user.ID, "a"); !errors.Is(err, repository.ErrCodeUsed) {
+		t.Fatalf("got %v, want %v", err, repository.ErrCodeUsed)
+	}
+
+	found, _ := users.FindByID(ctx, user.ID)
+	if len(found.MFA.RecoveryCodes) != 1 || found.MFA.RecoveryCodes[0] != "b" {
+		t.Fatalf("unexpected recovery codes: %v", found.MFA.RecoveryCodes)
+	}
+}
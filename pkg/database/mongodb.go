@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ayehia0/org/pkg/database/mongodb"
+	"github.com/ayehia0/org/pkg/database/redis"
+	"github.com/ayehia0/org/pkg/utils"
+)
+
+// the mongodb driver, the sessions are cached in redis
+type mongoDriver struct {
+	conn       *mongodb.MongoDBConn
+	redisConn  *redis.RedisConn
+	dbStore    *mongodb.DBStore
+	redisStore *redis.RedisStore
+}
+
+// connect to mongodb and redis
+func openMongoDB(dbConfig *utils.DatabaseConfig, redisConfig *utils.RedisConfig) (Driver, error) {
+	uri := fmt.Sprintf("mongodb://%s:%d", dbConfig.Host, dbConfig.Port)
+	conn, err := mongodb.NewMongoDBConn(uri, dbConfig.Database, dbConfig.Username, dbConfig.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	redisDb := 0 // the default db
+	redisConn, err := redis.NewRedisConn(redisConfig.Host, redisConfig.Port, redisConfig.Password, redisDb)
+	if err != nil {
+		conn.Client.Disconnect(context.Background())
+		return nil, err
+	}
+
+	return &mongoDriver{
+		conn:       conn,
+		redisConn:  redisConn,
+		dbStore:    mongodb.NewStore(conn),
+		redisStore: redis.NewStore(redisConn),
+	}, nil
+}
+
+func (d *mongoDriver) DBStore() *mongodb.DBStore {
+	return d.dbStore
+}
+
+func (d *mongoDriver) RedisStore() *redis.RedisStore {
+	return d.redisStore
+}
+
+func (d *mongoDriver) Migrate(ctx context.Context) (int, error) {
+	return mongodb.Migrate(ctx, d.conn)
+}
+
+func (d *mongoDriver) Close(ctx context.Context) error {
+	return errors.Join(
+		d.conn.Client.Disconnect(ctx),
+		d.redisConn.Client.Close(),
+	)
+}
+
+func (d *mongoDriver) HealthChecks() map[string]HealthCheck {
+	return map[string]HealthCheck{
+		"mongodb": func(ctx context.Context) error {
+			return d.conn.Client.Ping(ctx, nil)
+		},
+		"redis": func(ctx context.Context) error {
+			return d.redisConn.Client.Ping(ctx).Err()
+		},
+	}
+}
@@ -31,6 +31,7 @@ func NewMongoDBConn(uri, databaseName, username, password string) (*MongoDBConn,
 	// check the connection
 	err = client.Ping(context.Background(), nil)
 	if err != nil {
+		client.Disconnect(context.Background())
 		return nil, err
 	}
 
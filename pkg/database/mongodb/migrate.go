@@ -0,0 +1,161 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// the collections used to keep track of the migrations
+const (
+	migrationsCollection    = "schema_migrations"
+	migrationLockCollection = "schema_migrations_lock"
+)
+
+// how long a lock is kept if the instance holding it dies while migrating, the holder renews it while it's alive
+// the other instances wait for the lock up to migrationLockWait, long enough for the lock of a dead instance to expire
+const (
+	migrationLockTTL  = 5 * time.Minute
+	migrationLockWait = 2 * migrationLockTTL
+)
+
+var errMigrationLockTimeout = errors.New("timed out waiting for the migration lock")
+
+// a versioned change of the database: indexes or documents written by a previous version of the application
+// the migrations are applied once in the order of their versions, each one must be safe to run again if it fails half way
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, db *mongo.Database) error
+}
+
+// the record of an applied migration
+type appliedMigration struct {
+	Version   int       `bson:"_id"`
+	Name      string    `bson:"name"`
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+// the function to bring the database up to date, returns the number of applied migrations
+func Migrate(ctx context.Context, conn *MongoDBConn) (int, error) {
+	db := conn.Database
+
+	release, err := lockMigrations(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	// the versions already applied
+	applied := map[int]bool{}
+	cursor, err := db.Collection(migrationsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	records := []appliedMigration{}
+	if err := cursor.All(ctx, &records); err != nil {
+		return 0, err
+	}
+	for _, record := range records {
+		applied[record.Version] = true
+	}
+
+	count := 0
+	for _, m := range migrations {
+		if applied[m.Version] {
+			continue
+		}
+		if err := m.Up(ctx, db); err != nil {
+			return count, fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+		}
+		_, err := db.Collection(migrationsCollection).InsertOne(ctx, appliedMigration{
+			Version:   m.Version,
+			Name:      m.Name,
+			AppliedAt: time.Now(),
+		})
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// take the migration lock so two instances starting together don't both migrate, waits until the lock is free
+// the lock is renewed until it's released, so a long migration doesn't lose it to another instance
+func lockMigrations(ctx context.Context, db *mongo.Database) (func(), error) {
+	col := db.Collection(migrationLockCollection)
+	lockID := "migrations"
+	// the owner makes sure an instance only renews and releases its own lock
+	owner := primitive.NewObjectID().Hex()
+
+	// the callers usually have no deadline, the wait is bounded anyway
+	waitCtx, cancel := context.WithTimeout(ctx, migrationLockWait)
+	defer cancel()
+
+	for {
+		_, err := col.InsertOne(waitCtx, bson.M{"_id": lockID, "owner": owner, "expires_at": time.Now().Add(migrationLockTTL)})
+		if err == nil {
+			break
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, lockError(waitCtx, err)
+		}
+
+		// drop the lock of a dead instance, then try again
+		if _, err := col.DeleteOne(waitCtx, bson.M{"_id": lockID, "expires_at": bson.M{"$lt": time.Now()}}); err != nil {
+			return nil, lockError(waitCtx, err)
+		}
+		select {
+		case <-waitCtx.Done():
+			return nil, errMigrationLockTimeout
+		case <-time.After(time.Second):
+		}
+	}
+
+	// push the expiration back while migrating
+	done := make(chan struct{})
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		ticker := time.NewTicker(migrationLockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				res, err := col.UpdateOne(context.Background(),
+					bson.M{"_id": lockID, "owner": owner},
+					bson.M{"$set": bson.M{"expires_at": time.Now().Add(migrationLockTTL)}},
+				)
+				if err != nil {
+					slog.Warn("failed to renew the migration lock", "error", err)
+				} else if res.MatchedCount == 0 {
+					slog.Warn("the migration lock has been lost")
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-renewed
+		col.DeleteOne(context.Background(), bson.M{"_id": lockID, "owner": owner})
+	}, nil
+}
+
+// the error of a failed attempt to take the lock, the ones caused by the end of the wait are reported as a timeout
+func lockError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return errMigrationLockTimeout
+	}
+	return err
+}
@@ -0,0 +1,185 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// the migrations of the database, never change an applied migration, add a new one instead
+var migrations = []Migration{
+	{Version: 1, Name: "create_user_indexes", Up: createUserIndexes},
+	{Version: 2, Name: "create_organization_indexes", Up: createOrganizationIndexes},
+	{Version: 3, Name: "create_session_indexes", Up: createSessionIndexes},
+	{Version: 4, Name: "create_invitation_indexes", Up: createInvitationIndexes},
+	{Version: 5, Name: "set_session_families", Up: setSessionFamilies},
+	{Version: 6, Name: "verify_existing_users", Up: verifyExistingUsers},
+	{Version: 7, Name: "add_creators_as_owners", Up: addCreatorsAsOwners},
+}
+
+// the emails are unique, the index makes the signup safe against concurrent requests
+// the index can't be built over duplicated emails, they are reported first so the operators can merge or delete them
+func createUserIndexes(ctx context.Context, db *mongo.Database) error {
+	if err := checkDuplicateEmails(ctx, db.Collection("users")); err != nil {
+		return err
+	}
+
+	_, err := db.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// the users sharing an email, reported with their ids
+func checkDuplicateEmails(ctx context.Context, users *mongo.Collection) error {
+	cursor, err := users.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$email", "ids": bson.M{"$push": "$_id"}, "count": bson.M{"$sum": 1}}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	var duplicates []struct {
+		Email string               `bson:"_id"`
+		IDs   []primitive.ObjectID `bson:"ids"`
+	}
+	if err := cursor.All(ctx, &duplicates); err != nil {
+		return err
+	}
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	lines := make([]string, len(duplicates))
+	for i, duplicate := range duplicates {
+		ids := make([]string, len(duplicate.IDs))
+		for j, id := range duplicate.IDs {
+			ids[j] = id.Hex()
+		}
+		lines[i] = fmt.Sprintf("%s: %s", duplicate.Email, strings.Join(ids, ", "))
+	}
+	return fmt.Errorf("the emails must be unique but %d of them are shared, merge or delete the duplicated users before migrating:\n%s",
+		len(duplicates), strings.Join(lines, "\n"))
+}
+
+// the organizations are looked up by their members and by their creator
+func createOrganizationIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("organizations").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "members.email", Value: 1}}},
+		{Keys: bson.D{{Key: "members._id", Value: 1}}},
+		{Keys: bson.D{{Key: "creator", Value: 1}}},
+	})
+	return err
+}
+
+// the sessions are looked up by user, family and access token, they are deleted once the refresh token expires
+func createSessionIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("sessions").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "family_id", Value: 1}}},
+		{Keys: bson.D{{Key: "access_token_id", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "refresh_token_expires", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	return err
+}
+
+// the invitations are looked up by email and by organization
+func createInvitationIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("invitations").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "email", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "organization_id", Value: 1}, {Key: "email", Value: 1}, {Key: "status", Value: 1}}},
+	})
+	return err
+}
+
+// the sessions created before the refresh token rotation are the first of their own family
+func setSessionFamilies(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("sessions").UpdateMany(ctx,
+		bson.M{"family_id": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"family_id": "$_id"}}}},
+	)
+	return err
+}
+
+// the users who signed up before the email verification keep their access
+func verifyExistingUsers(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("users").UpdateMany(ctx,
+		bson.M{"email_verified": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"email_verified": true}},
+	)
+	return err
+}
+
+// the organizations created before the access levels didn't list their creator as a member
+func addCreatorsAsOwners(ctx context.Context, db *mongo.Database) error {
+	orgs := db.Collection("organizations")
+	users := db.Collection("users")
+
+	cursor, err := orgs.Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var org models.Organization
+		if err := cursor.Decode(&org); err != nil {
+			return err
+		}
+
+		isMember := false
+		for _, member := range org.Members {
+			if member.ID == org.Creator {
+				isMember = true
+				break
+			}
+		}
+		if isMember {
+			continue
+		}
+
+		var creator models.User
+		objectID, err := utils.StringToObjectID(org.Creator)
+		if err != nil {
+			continue
+		}
+		if err := users.FindOne(ctx, bson.M{"_id": objectID}).Decode(&creator); err != nil {
+			// the creator doesn't exist anymore, nothing to add
+			if err == mongo.ErrNoDocuments {
+				continue
+			}
+			return err
+		}
+
+		orgID, err := utils.StringToObjectID(org.ID)
+		if err != nil {
+			return err
+		}
+		_, err = orgs.UpdateOne(ctx,
+			bson.M{"_id": orgID, "members._id": bson.M{"$ne": org.Creator}},
+			bson.M{"$push": bson.M{"members": models.Member{
+				ID:          creator.ID,
+				Name:        creator.Name,
+				Email:       creator.Email,
+				AccessLevel: models.AccessLevelOwner,
+			}}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
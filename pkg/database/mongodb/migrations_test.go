@@ -0,0 +1,43 @@
+package mongodb
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestCreateUserIndexesReportsTheDuplicateEmails(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("duplicates", func(mt *mtest.T) {
+		first, second := primitive.NewObjectID(), primitive.NewObjectID()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "org.users", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: "alice@example.com"}, {Key: "ids", Value: bson.A{first, second}}, {Key: "count", Value: 2}},
+		))
+
+		// the index isn't created, the error names the email and the users sharing it
+		err := createUserIndexes(context.Background(), mt.DB)
+		if err == nil {
+			mt.Fatal("the index has been created over the duplicated emails")
+		}
+		for _, want := range []string{"alice@example.com", first.Hex(), second.Hex()} {
+			if !strings.Contains(err.Error(), want) {
+				mt.Fatalf("the error doesn't mention %s: %v", want, err)
+			}
+		}
+	})
+
+	mt.Run("unique", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "org.users", mtest.FirstBatch),
+			mtest.CreateSuccessResponse(),
+		)
+		if err := createUserIndexes(context.Background(), mt.DB); err != nil {
+			mt.Fatal(err)
+		}
+	})
+}
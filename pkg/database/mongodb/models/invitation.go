@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// the states an invitation goes through
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusDeclined = "declined"
+	InvitationStatusExpired  = "expired"
+)
+
+// Invitation is a struct that represents the invitation model, an invitation is sent by a member of an organization
+// to an email and it's up to the invitee to accept or decline it before it expires
+type Invitation struct {
+	ID               string    `json:"id" bson:"_id,omitempty"`
+	OrganizationID   string    `json:"organization_id" bson:"organization_id"`
+	OrganizationName string    `json:"organization_name" bson:"organization_name"`
+	Email            string    `json:"email" bson:"email"`
+	UserID           string    `json:"user_id" bson:"user_id"`
+	AccessLevel      string    `json:"access_level" bson:"access_level"`
+	InvitedBy        string    `json:"invited_by" bson:"invited_by"`
+	Status           string    `json:"status" bson:"status"`
+	ExpiresAt        time.Time `json:"expires_at" bson:"expires_at"`
+	CreatedAt        time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at" bson:"updated_at"`
+}
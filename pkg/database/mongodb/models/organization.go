@@ -1,5 +1,16 @@
 package models
 
+import "time"
+
+// the access levels (roles) a member can have inside an organization
+// ordered from the most privileged to the least privileged
+const (
+	AccessLevelOwner  = "owner"
+	AccessLevelAdmin  = "admin"
+	AccessLevelMember = "member"
+	AccessLevelViewer = "viewer"
+)
+
 // Organization is a struct that represents the organization model
 // the organization have a name, description and members
 type Member struct {
@@ -15,4 +26,15 @@ type Organization struct {
 	Desc    string   `json:"description" bson:"desc"`
 	Creator string   `json:"creator" bson:"creator"`
 	Members []Member `json:"members" bson:"members"`
+
+	// the ownership transfer waiting for the target member to accept it
+	PendingTransfer *OwnershipTransfer `json:"pending_transfer,omitempty" bson:"pending_transfer,omitempty"`
+}
+
+// OwnershipTransfer is proposed by an owner to another member, once accepted the member becomes the creator
+type OwnershipTransfer struct {
+	From      string    `json:"from" bson:"from"`
+	To        string    `json:"to" bson:"to"`
+	ExpiresAt time.Time `json:"expires_at" bson:"expires_at"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
 }
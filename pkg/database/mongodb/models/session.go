@@ -3,11 +3,22 @@ package models
 import "time"
 
 // Session is a struct that represents the session model, the session model is used to store the user session (access_token, refresh_token, and the user id)
+// every refresh rotates the session: a new session is created in the same family and the old one is marked as replaced
 type Session struct {
 	ID                  string    `json:"id" bson:"_id,omitempty"`
 	AccessToken         string    `json:"access_token" bson:"access_token"`
+	AccessTokenID       string    `json:"access_token_id" bson:"access_token_id"`
 	AccessTokenExpires  time.Time `json:"access_token_expires" bson:"access_token_expires"`
 	RefreshToken        string    `json:"refresh_token" bson:"refresh_token"`
 	RefreshTokenExpires time.Time `json:"refresh_token_expires" bson:"refresh_token_expires"`
 	UserID              string    `json:"user_id" bson:"user_id"`
+	FamilyID            string    `json:"family_id" bson:"family_id"`
+	ReplacedBy          string    `json:"replaced_by,omitempty" bson:"replaced_by,omitempty"`
+
+	// the device metadata, the creation time is the time of the login and kept across rotations
+	// the last use is moved by the authenticated requests, at most once a minute
+	UserAgent  string    `json:"user_agent" bson:"user_agent"`
+	IP         string    `json:"ip" bson:"ip"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at" bson:"last_used_at"`
 }
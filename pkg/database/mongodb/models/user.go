@@ -6,4 +6,23 @@ type User struct {
 	Name     string `json:"name" bson:"name"`
 	Email    string `json:"email" bson:"email"`
 	Password string `json:"password" bson:"password"`
+	MFA      MFA    `json:"-" bson:"mfa"`
+
+	// the user has proved that he owns the email
+	EmailVerified bool `json:"email_verified" bson:"email_verified"`
+
+	// a disabled user can't log in anymore, only the operators can disable or enable a user
+	Disabled bool `json:"disabled" bson:"disabled"`
+
+	// the time step of the last accepted code from the authenticator app, the codes of this step or before are refused
+	// the steps only grow with the time so it's kept when the two factor authentication is disabled or enrolled again
+	LastTOTPStep int64 `json:"-" bson:"last_totp_step"`
+}
+
+// MFA holds the two factor authentication settings of the user
+// the secret is saved on enrollment but only used for the login once the user confirms it (Enabled)
+type MFA struct {
+	Enabled       bool     `bson:"enabled"`
+	Secret        string   `bson:"secret"`
+	RecoveryCodes []string `bson:"recovery_codes"` // hashed, each code can be used once
 }
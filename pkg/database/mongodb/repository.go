@@ -7,6 +7,7 @@ type DBStore struct {
 	UserRepository         repository.UserRepository
 	SessionRepository      repository.SessionRepository
 	OrganizationRepository repository.OrganizationRepository
+	InvitationRepository   repository.InvitationRepository
 }
 
 func NewStore(conn *MongoDBConn) *DBStore {
@@ -15,15 +16,18 @@ func NewStore(conn *MongoDBConn) *DBStore {
 	userCol := conn.Database.Collection("users")
 	sessionCol := conn.Database.Collection("sessions")
 	orgCol := conn.Database.Collection("organizations")
+	invitationCol := conn.Database.Collection("invitations")
 
 	// create the user repository
 	user := repository.NewUserRepository(userCol)
 	session := repository.NewSessionRepository(sessionCol)
 	org := repository.NewOrganizationRepository(orgCol)
+	invitation := repository.NewInvitationRepository(invitationCol)
 
 	return &DBStore{
 		UserRepository:         user,
 		SessionRepository:      session,
 		OrganizationRepository: org,
+		InvitationRepository:   invitation,
 	}
 }
@@ -0,0 +1,19 @@
+package repository
+
+import "github.com/ayehia0/org/pkg/apperror"
+
+// the errors returned by the repositories of every driver, the ids that can't be parsed are reported as not found
+var (
+	ErrUserNotFound         = apperror.New(apperror.KindNotFound, "user_not_found", "user not found")
+	ErrEmailExists          = apperror.New(apperror.KindConflict, "email_exists", "email already exists")
+	ErrOrganizationNotFound = apperror.New(apperror.KindNotFound, "organization_not_found", "organization not found")
+	ErrMemberNotFound       = apperror.New(apperror.KindNotFound, "member_not_found", "member not found")
+	ErrMemberExists         = apperror.New(apperror.KindConflict, "member_exists", "member already exists")
+	ErrTransferNotFound     = apperror.New(apperror.KindNotFound, "transfer_not_found", "ownership transfer not found")
+	ErrSessionNotFound      = apperror.New(apperror.KindNotFound, "session_not_found", "session not found")
+	ErrSessionExists        = apperror.New(apperror.KindConflict, "session_exists", "session already exists")
+	ErrSessionRotated       = apperror.New(apperror.KindConflict, "session_rotated", "session has already been rotated")
+	ErrInvitationNotFound   = apperror.New(apperror.KindNotFound, "invitation_not_found", "invitation not found")
+	ErrInvalidCursor        = apperror.New(apperror.KindInvalid, "invalid_cursor", "invalid cursor")
+	ErrCodeUsed             = apperror.New(apperror.KindConflict, "code_used", "code has already been used")
+)
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/tracing"
+	"github.com/ayehia0/org/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// the repository package contains the database operations for the invitation model
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation *models.Invitation) (string, error)         // Create a new invitation and return the id as a string
+	FindByID(ctx context.Context, id string) (*models.Invitation, error)               // Find an invitation by id
+	FindPendingByEmail(ctx context.Context, email string) ([]models.Invitation, error) // Find the pending invitations sent to an email
+	HasPending(ctx context.Context, orgID string, email string) (bool, error)          // Check if an email has a pending invitation to an organization
+	UpdateStatus(ctx context.Context, id string, status string) error                  // Move the invitation to a new state
+	AttachUser(ctx context.Context, email string, userID string) (int64, error)        // Link the pending invitations of an email to a newly created user
+}
+
+// the invitation repository struct
+type invitationRepository struct {
+	col *mongo.Collection
+}
+
+// create a new invitation repository
+func NewInvitationRepository(col *mongo.Collection) InvitationRepository {
+	return &invitationRepository{col: col}
+}
+
+// the function to create a new invitation
+func (r *invitationRepository) Create(ctx context.Context, invitation *models.Invitation) (string, error) {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "invitations", "Create")
+	defer end()
+
+	res, err := r.col.InsertOne(ctx, invitation)
+	if err != nil {
+		return "", err
+	}
+	id, ok := res.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return "", errors.New("error converting id to string")
+	}
+	return id.Hex(), nil
+}
+
+// the function to find an invitation by id
+func (r *invitationRepository) FindByID(ctx context.Context, id string) (*models.Invitation, error) {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "invitations", "FindByID")
+	defer end()
+
+	var invitation models.Invitation
+
+	objectID, err := utils.StringToObjectID(id)
+	if err != nil {
+		return nil, ErrInvitationNotFound
+	}
+	err = r.col.FindOne(ctx, bson.M{"_id": objectID}).Decode(&invitation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// the function to find all the pending invitations of an email
+func (r *invitationRepository) FindPendingByEmail(ctx context.Context, email string) ([]models.Invitation, error) {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "invitations", "FindPendingByEmail")
+	defer end()
+
+	invitations := []models.Invitation{}
+	cursor, err := r.col.Find(ctx, bson.M{"email": email, "status": models.InvitationStatusPending})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &invitations); err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// the function to check if the email already has a pending invitation to the organization
+func (r *invitationRepository) HasPending(ctx context.Context, orgID string, email string) (bool, error) {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "invitations", "HasPending")
+	defer end()
+
+	count, err := r.col.CountDocuments(ctx, bson.M{
+		"organization_id": orgID,
+		"email":           email,
+		"status":          models.InvitationStatusPending,
+		"expires_at":      bson.M{"$gt": time.Now()},
+	})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// the function to change the status of an invitation
+func (r *invitationRepository) UpdateStatus(ctx context.Context, id string, status string) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "invitations", "UpdateStatus")
+	defer end()
+
+	objectID, err := utils.StringToObjectID(id)
+	if err != nil {
+		return ErrInvitationNotFound
+	}
+	res, err := r.col.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{
+		"$set": bson.M{
+			"status":     status,
+			"updated_at": time.Now(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+// the function to link the pending invitations sent to an email before signing up to the user
+func (r *invitationRepository) AttachUser(ctx context.Context, email string, userID string) (int64, error) {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "invitations", "AttachUser")
+	defer end()
+
+	res, err := r.col.UpdateMany(ctx, bson.M{
+		"email":  email,
+		"status": models.InvitationStatusPending,
+	}, bson.M{
+		"$set": bson.M{
+			"user_id":    userID,
+			"updated_at": time.Now(),
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
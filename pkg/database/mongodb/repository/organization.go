@@ -2,15 +2,42 @@ package repository
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"regexp"
 
 	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/tracing"
 	"github.com/ayehia0/org/pkg/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// the sort orders supported when listing the organizations
+const (
+	SortByName    = "name"
+	SortByCreated = "created"
+)
+
+// the options to list the organizations of a member page by page
+type OrganizationListOptions struct {
+	MemberID   string // the creator or a member of the organizations
+	Name       string // case insensitive search in the name
+	SortBy     string // SortByName or SortByCreated (default)
+	Descending bool
+	Cursor     string // the cursor returned with the previous page, empty for the first page
+	Limit      int
+}
+
+// the position of the last organization of a page, shared by all the storage drivers
+type ListCursor struct {
+	Name string `json:"n,omitempty"`
+	ID   string `json:"id"`
+}
+
 // the repository package contains the database operations for the organization model
 type OrganizationRepository interface {
 	Create(ctx context.Context, org *models.Organization) (string, error)               // Create a new organization and return the id as a string
@@ -19,8 +46,17 @@ type OrganizationRepository interface {
 	Delete(ctx context.Context, id string) error                                        // Delete an organization
 	AddMember(ctx context.Context, orgID string, member *models.Member) error           // Add a member to an organization
 	FindAll(ctx context.Context) ([]models.Organization, error)                         // Find all organizations
-	InviteUserToOrganization(ctx context.Context, orgID string, member models.Member) error
 	IsUserInOrganization(ctx context.Context, orgID string, email string) (bool, error)
+	FindByMember(ctx context.Context, userID string) ([]models.Organization, error)                        // Find the organizations a user created or is a member of
+	ListByMember(ctx context.Context, opts OrganizationListOptions) ([]models.Organization, string, error) // Find a page of the organizations of a user, returns the cursor of the next page
+	UpdateMemberAccessLevel(ctx context.Context, orgID string, userID string, accessLevel string) error    // Change the access level of a member
+	UpdateMemberName(ctx context.Context, userID string, name string) error                                // Change the name of a user in all the organizations he is a member of
+	RemoveMember(ctx context.Context, orgID string, userID string) error                                   // Remove a member from an organization
+	RemoveMemberFromAll(ctx context.Context, userID string) error                                          // Remove a user from all the organizations
+	SetCreator(ctx context.Context, orgID string, userID string) error                                     // Change the creator of an organization
+	ProposeTransfer(ctx context.Context, orgID string, transfer *models.OwnershipTransfer) error           // Save a pending ownership transfer
+	ClearTransfer(ctx context.Context, orgID string) error                                                 // Drop the pending ownership transfer
+	CompleteTransfer(ctx context.Context, orgID string, from string, to string) error                      // Atomically make the target the creator and an owner, the previous owner (who must still be one) becomes an admin
 }
 
 // the organization repository struct
@@ -36,6 +72,9 @@ func NewOrganizationRepository(col *mongo.Collection) OrganizationRepository {
 
 // the function to create a new organization
 func (r *organizationRepository) Create(ctx context.Context, org *models.Organization) (string, error) {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "organizations", "Create")
+	defer end()
+
 	// create a new organization and return the created organization
 	res, err := r.col.InsertOne(ctx, org)
 	if err != nil {
@@ -50,16 +89,19 @@ func (r *organizationRepository) Create(ctx context.Context, org *models.Organiz
 
 // the function to find an organization by id
 func (r *organizationRepository) FindByID(ctx context.Context, id string) (*models.Organization, error) {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "organizations", "FindByID")
+	defer end()
+
 	var org models.Organization
 
 	objectID, err := utils.StringToObjectID(id)
 	if err != nil {
-		return nil, err
+		return nil, ErrOrganizationNotFound
 	}
 	err = r.col.FindOne(ctx, bson.M{"_id": objectID}).Decode(&org)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("organization not found")
+			return nil, ErrOrganizationNotFound
 		}
 	}
 	return &org, err
@@ -68,10 +110,12 @@ func (r *organizationRepository) FindByID(ctx context.Context, id string) (*mode
 // the function to update an organization
 // update only the given fields
 func (r *organizationRepository) Update(ctx context.Context, org *models.Organization) (*models.Organization, error) {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "organizations", "Update")
+	defer end()
 
 	objectID, err := utils.StringToObjectID(org.ID)
 	if err != nil {
-		return nil, err
+		return nil, ErrOrganizationNotFound
 	}
 	updateFields := bson.M{
 		"$set": bson.M{
@@ -82,7 +126,7 @@ func (r *organizationRepository) Update(ctx context.Context, org *models.Organiz
 	_, err = r.col.UpdateOne(ctx, bson.M{"_id": objectID}, updateFields)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("organization not found")
+			return nil, ErrOrganizationNotFound
 		}
 	}
 	return org, err
@@ -90,15 +134,17 @@ func (r *organizationRepository) Update(ctx context.Context, org *models.Organiz
 
 // the function to delete an organization
 func (r *organizationRepository) Delete(ctx context.Context, id string) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "organizations", "Delete")
+	defer end()
 
 	objectID, err := utils.StringToObjectID(id)
 	if err != nil {
-		return err
+		return ErrOrganizationNotFound
 	}
 	_, err = r.col.DeleteOne(ctx, bson.M{"_id": objectID})
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return errors.New("organization not found")
+			return ErrOrganizationNotFound
 		}
 	}
 	return err
@@ -106,21 +152,39 @@ func (r *organizationRepository) Delete(ctx context.Context, id string) error {
 
 // the function to add a member to an organization
 func (r *organizationRepository) AddMember(ctx context.Context, orgID string, member *models.Member) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "organizations", "AddMember")
+	defer end()
 
 	objectID, err := utils.StringToObjectID(orgID)
 	if err != nil {
-		return err
+		return ErrOrganizationNotFound
 	}
-	_, err = r.col.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$push": bson.M{"members": member}})
+	// the member is only pushed if not already there, so two concurrent accepts can't add the user twice
+	res, err := r.col.UpdateOne(ctx,
+		bson.M{"_id": objectID, "members._id": bson.M{"$ne": member.ID}},
+		bson.M{"$push": bson.M{"members": member}},
+	)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return errors.New("organization not found")
+		return err
+	}
+	if res.MatchedCount == 0 {
+		// either the organization doesn't exist or the user is already a member
+		count, err := r.col.CountDocuments(ctx, bson.M{"_id": objectID})
+		if err != nil {
+			return err
 		}
+		if count == 0 {
+			return ErrOrganizationNotFound
+		}
+		return ErrMemberExists
 	}
-	return err
+	return nil
 }
 
 func (r *organizationRepository) FindAll(ctx context.Context) ([]models.Organization, error) {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "organizations", "FindAll")
+	defer end()
+
 	var orgs []models.Organization
 	cursor, err := r.col.Find(ctx, bson.M{})
 	if err != nil {
@@ -132,29 +196,294 @@ func (r *organizationRepository) FindAll(ctx context.Context) ([]models.Organiza
 	return orgs, nil
 }
 
-func (r *organizationRepository) InviteUserToOrganization(ctx context.Context, orgID string, member models.Member) error {
+func (r *organizationRepository) IsUserInOrganization(ctx context.Context, orgID string, email string) (bool, error) {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "organizations", "IsUserInOrganization")
+	defer end()
+
+	orgObjectId, err := utils.StringToObjectID(orgID)
+	if err != nil {
+		return false, ErrOrganizationNotFound
+	}
+
+	count, err := r.col.CountDocuments(ctx, bson.M{"_id": orgObjectId, "members.email": email})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// the function to find the organizations a user created or is a member of
+func (r *organizationRepository) FindByMember(ctx context.Context, userID string) ([]models.Organization, error) {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "organizations", "FindByMember")
+	defer end()
+
+	orgs := []models.Organization{}
+	cursor, err := r.col.Find(ctx, bson.M{"$or": bson.A{
+		bson.M{"creator": userID},
+		bson.M{"members._id": userID},
+	}})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &orgs); err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// the function to change the access level of a member
+func (r *organizationRepository) UpdateMemberAccessLevel(ctx context.Context, orgID string, userID string, accessLevel string) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "organizations", "UpdateMemberAccessLevel")
+	defer end()
+
 	objectID, err := utils.StringToObjectID(orgID)
+	if err != nil {
+		return ErrOrganizationNotFound
+	}
+	res, err := r.col.UpdateOne(ctx,
+		bson.M{"_id": objectID, "members._id": userID},
+		bson.M{"$set": bson.M{"members.$.accessLevel": accessLevel}},
+	)
 	if err != nil {
 		return err
 	}
-	_, err = r.col.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$push": bson.M{"members": member}})
+	if res.MatchedCount == 0 {
+		return ErrMemberNotFound
+	}
+	return nil
+}
+
+// the function to change the name of a user in every organization he is a member of
+func (r *organizationRepository) UpdateMemberName(ctx context.Context, userID string, name string) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "organizations", "UpdateMemberName")
+	defer end()
+
+	_, err := r.col.UpdateMany(ctx,
+		bson.M{"members._id": userID},
+		bson.M{"$set": bson.M{"members.$[member].name": name}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: bson.A{bson.M{"member._id": userID}},
+		}),
+	)
+	return err
+}
+
+// the function to remove a member from an organization
+func (r *organizationRepository) RemoveMember(ctx context.Context, orgID string, userID string) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "organizations", "RemoveMember")
+	defer end()
+
+	objectID, err := utils.StringToObjectID(orgID)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return errors.New("organization not found")
-		}
+		return ErrOrganizationNotFound
+	}
+	res, err := r.col.UpdateOne(ctx,
+		bson.M{"_id": objectID, "members._id": userID},
+		bson.M{"$pull": bson.M{"members": bson.M{"_id": userID}}},
+	)
+	if err != nil {
+		return err
 	}
+	if res.MatchedCount == 0 {
+		return ErrMemberNotFound
+	}
+	return nil
+}
+
+// the function to remove a user from all the organizations
+func (r *organizationRepository) RemoveMemberFromAll(ctx context.Context, userID string) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "organizations", "RemoveMemberFromAll")
+	defer end()
+
+	_, err := r.col.UpdateMany(ctx,
+		bson.M{"members._id": userID},
+		bson.M{"$pull": bson.M{"members": bson.M{"_id": userID}}},
+	)
 	return err
 }
 
-func (r *organizationRepository) IsUserInOrganization(ctx context.Context, orgID string, email string) (bool, error) {
-	orgObjectId, err := utils.StringToObjectID(orgID)
+// the function to change the creator of an organization
+func (r *organizationRepository) SetCreator(ctx context.Context, orgID string, userID string) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "organizations", "SetCreator")
+	defer end()
+
+	objectID, err := utils.StringToObjectID(orgID)
 	if err != nil {
-		return false, err
+		return ErrOrganizationNotFound
+	}
+	res, err := r.col.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"creator": userID}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrOrganizationNotFound
 	}
+	return nil
+}
 
-	count, err := r.col.CountDocuments(ctx, bson.M{"_id": orgObjectId, "members.email": email})
+// the function to save a pending ownership transfer, it replaces the previous one if any
+func (r *organizationRepository) ProposeTransfer(ctx context.Context, orgID string, transfer *models.OwnershipTransfer) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "organizations", "ProposeTransfer")
+	defer end()
+
+	objectID, err := utils.StringToObjectID(orgID)
 	if err != nil {
-		return false, err
+		return ErrOrganizationNotFound
 	}
-	return count > 0, nil
+	res, err := r.col.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"pending_transfer": transfer}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrOrganizationNotFound
+	}
+	return nil
+}
+
+// the function to drop the pending ownership transfer
+func (r *organizationRepository) ClearTransfer(ctx context.Context, orgID string) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "organizations", "ClearTransfer")
+	defer end()
+
+	objectID, err := utils.StringToObjectID(orgID)
+	if err != nil {
+		return ErrOrganizationNotFound
+	}
+	_, err = r.col.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$unset": bson.M{"pending_transfer": ""}})
+	return err
+}
+
+// the function to complete the ownership transfer, everything happens in a single update of the document
+// so the organization is never seen half transferred, the update only matches if the transfer is still pending
+// and its proposer is still an owner (the creator is always one)
+func (r *organizationRepository) CompleteTransfer(ctx context.Context, orgID string, from string, to string) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "organizations", "CompleteTransfer")
+	defer end()
+
+	objectID, err := utils.StringToObjectID(orgID)
+	if err != nil {
+		return ErrOrganizationNotFound
+	}
+	res, err := r.col.UpdateOne(ctx,
+		bson.M{
+			"_id":                   objectID,
+			"pending_transfer.from": from,
+			"pending_transfer.to":   to,
+			"members._id":           to,
+			"$or": bson.A{
+				bson.M{"creator": from},
+				bson.M{"members": bson.M{"$elemMatch": bson.M{"_id": from, "accessLevel": models.AccessLevelOwner}}},
+			},
+		},
+		bson.M{
+			"$set": bson.M{
+				"creator":                     to,
+				"members.$[to].accessLevel":   models.AccessLevelOwner,
+				"members.$[from].accessLevel": models.AccessLevelAdmin,
+			},
+			"$unset": bson.M{"pending_transfer": ""},
+		},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: bson.A{bson.M{"to._id": to}, bson.M{"from._id": from}},
+		}),
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrTransferNotFound
+	}
+	return nil
+}
+
+// the function to list the organizations of a member page by page
+// the pagination is based on a cursor (the sort key and the id of the last organization) so the pages are stable
+func (r *organizationRepository) ListByMember(ctx context.Context, opts OrganizationListOptions) ([]models.Organization, string, error) {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "organizations", "ListByMember")
+	defer end()
+
+	conditions := bson.A{
+		bson.M{"$or": bson.A{
+			bson.M{"creator": opts.MemberID},
+			bson.M{"members._id": opts.MemberID},
+		}},
+	}
+
+	if opts.Name != "" {
+		conditions = append(conditions, bson.M{"name": bson.M{"$regex": regexp.QuoteMeta(opts.Name), "$options": "i"}})
+	}
+
+	direction, operator := 1, "$gt"
+	if opts.Descending {
+		direction, operator = -1, "$lt"
+	}
+
+	// continue right after the last organization of the previous page
+	if opts.Cursor != "" {
+		cursor, err := DecodeCursor(opts.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		lastID, err := utils.StringToObjectID(cursor.ID)
+		if err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+		if opts.SortBy == SortByName {
+			conditions = append(conditions, bson.M{"$or": bson.A{
+				bson.M{"name": bson.M{operator: cursor.Name}},
+				bson.M{"name": cursor.Name, "_id": bson.M{operator: lastID}},
+			}})
+		} else {
+			conditions = append(conditions, bson.M{"_id": bson.M{operator: lastID}})
+		}
+	}
+
+	// the ids are increasing with the creation time
+	sort := bson.D{{Key: "_id", Value: direction}}
+	if opts.SortBy == SortByName {
+		sort = bson.D{{Key: "name", Value: direction}, {Key: "_id", Value: direction}}
+	}
+
+	// fetch one more organization to know if there is a next page
+	findOptions := options.Find().SetSort(sort).SetLimit(int64(opts.Limit + 1))
+	cursor, err := r.col.Find(ctx, bson.M{"$and": conditions}, findOptions)
+	if err != nil {
+		return nil, "", err
+	}
+
+	orgs := []models.Organization{}
+	if err := cursor.All(ctx, &orgs); err != nil {
+		return nil, "", err
+	}
+
+	if len(orgs) <= opts.Limit {
+		return orgs, "", nil
+	}
+
+	orgs = orgs[:opts.Limit]
+	last := orgs[len(orgs)-1]
+	next := ListCursor{ID: last.ID}
+	if opts.SortBy == SortByName {
+		next.Name = last.Name
+	}
+	return orgs, EncodeCursor(next), nil
+}
+
+// returns the opaque value of the cursor given to the clients
+func EncodeCursor(cursor ListCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// parse the cursor sent back by the clients
+func DecodeCursor(value string) (*ListCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor ListCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
 }
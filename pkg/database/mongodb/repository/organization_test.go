@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestAddMemberTwice(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	orgID := primitive.NewObjectID().Hex()
+	member := &models.Member{ID: "user", AccessLevel: models.AccessLevelMember}
+
+	mt.Run("added", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		if err := NewOrganizationRepository(mt.Coll).AddMember(context.Background(), orgID, member); err != nil {
+			mt.Fatal(err)
+		}
+	})
+
+	// the filter skips the organizations the user is already a member of
+	mt.Run("member", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+			mtest.CreateCursorResponse(0, "org.organizations", mtest.FirstBatch, bson.D{{Key: "n", Value: 1}}),
+		)
+		err := NewOrganizationRepository(mt.Coll).AddMember(context.Background(), orgID, member)
+		if !errors.Is(err, ErrMemberExists) {
+			mt.Fatalf("got %v, want %v", err, ErrMemberExists)
+		}
+	})
+
+	mt.Run("no organization", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+			mtest.CreateCursorResponse(0, "org.organizations", mtest.FirstBatch),
+		)
+		err := NewOrganizationRepository(mt.Coll).AddMember(context.Background(), orgID, member)
+		if !errors.Is(err, ErrOrganizationNotFound) {
+			mt.Fatalf("got %v, want %v", err, ErrOrganizationNotFound)
+		}
+	})
+}
@@ -2,19 +2,28 @@ package repository
 
 import (
 	"context"
-	"errors"
+	"time"
 
 	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/tracing"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // the repository package contains the database operations for the session model
 type SessionRepository interface {
-	Create(ctx context.Context, session *models.Session) error                // Create a new Session
-	FindByID(ctx context.Context, id string) (*models.Session, error)         // Find a Session by id
-	FindByUserID(ctx context.Context, userID string) (*models.Session, error) // Find a Session by user id
-	Delete(ctx context.Context, id string) error                              // Delete a Session
+	Create(ctx context.Context, session *models.Session) error                              // Create a new Session
+	FindByID(ctx context.Context, id string) (*models.Session, error)                       // Find a Session by id
+	FindByUserID(ctx context.Context, userID string) ([]models.Session, error)              // Find the active (not rotated) Sessions of a user
+	FindAllByUserID(ctx context.Context, userID string) ([]models.Session, error)           // Find all the Sessions of a user, the rotated ones included
+	FindByAccessTokenID(ctx context.Context, tokenID string) (*models.Session, error)       // Find the Session an access token was issued for
+	FindByFamilyID(ctx context.Context, familyID string) ([]models.Session, error)          // Find all the Sessions of a token family
+	MarkRotated(ctx context.Context, id string, replacedBy string) error                    // Mark a Session as replaced, fails with ErrSessionRotated if already replaced
+	Touch(ctx context.Context, tokenID string, usedAt time.Time, every time.Duration) error // Set the last use of the Session of an access token, at most once every duration
+	Delete(ctx context.Context, id string) error                                            // Delete a Session
+	DeleteByFamilyID(ctx context.Context, familyID string) error                            // Delete all the Sessions of a token family
+	DeleteByUserID(ctx context.Context, userID string) error                                // Delete all the Sessions of a user
+	DeleteExpired(ctx context.Context) (int64, error)                                       // Delete the Sessions whose refresh token has expired, returns how many were deleted
 }
 
 // the session repository struct
@@ -30,10 +39,13 @@ func NewSessionRepository(col *mongo.Collection) SessionRepository {
 
 // the function to create a new session
 func (r *sessionRepository) Create(ctx context.Context, session *models.Session) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "sessions", "Create")
+	defer end()
+
 	_, err := r.col.InsertOne(ctx, session)
 	if err != nil {
 		if mongo.IsDuplicateKeyError(err) {
-			return errors.New("session already exists")
+			return ErrSessionExists
 		}
 	}
 	return err
@@ -42,35 +54,156 @@ func (r *sessionRepository) Create(ctx context.Context, session *models.Session)
 // the function to find a session by id
 // TODO: change this to use the object id as key instead of string
 func (r *sessionRepository) FindByID(ctx context.Context, id string) (*models.Session, error) {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "sessions", "FindByID")
+	defer end()
+
 	var session models.Session
 	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&session)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("session not found")
+			return nil, ErrSessionNotFound
 		}
 	}
 	return &session, err
 }
 
-// the function to find a session by user id
-func (r *sessionRepository) FindByUserID(ctx context.Context, userID string) (*models.Session, error) {
+// the function to find the active sessions of a user, the rotated ones are only kept to detect reuse
+func (r *sessionRepository) FindByUserID(ctx context.Context, userID string) ([]models.Session, error) {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "sessions", "FindByUserID")
+	defer end()
+
+	sessions := []models.Session{}
+	cursor, err := r.col.Find(ctx, bson.M{"user_id": userID, "replaced_by": bson.M{"$exists": false}})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// the function to find all the sessions of a user, the rotated ones included
+func (r *sessionRepository) FindAllByUserID(ctx context.Context, userID string) ([]models.Session, error) {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "sessions", "FindAllByUserID")
+	defer end()
+
+	sessions := []models.Session{}
+	cursor, err := r.col.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// the function to find the session of an access token
+func (r *sessionRepository) FindByAccessTokenID(ctx context.Context, tokenID string) (*models.Session, error) {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "sessions", "FindByAccessTokenID")
+	defer end()
+
 	var session models.Session
-	err := r.col.FindOne(ctx, bson.M{"user_id": userID}).Decode(&session)
+	err := r.col.FindOne(ctx, bson.M{"access_token_id": tokenID}).Decode(&session)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("session not found")
+			return nil, ErrSessionNotFound
 		}
+		return nil, err
 	}
-	return &session, err
+	return &session, nil
 }
 
 // the function to delete a session
 func (r *sessionRepository) Delete(ctx context.Context, id string) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "sessions", "Delete")
+	defer end()
+
 	_, err := r.col.DeleteOne(ctx, bson.M{"_id": id})
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return errors.New("session not found")
+			return ErrSessionNotFound
 		}
 	}
 	return err
 }
+
+// the function to find all the sessions that belong to the same token family
+func (r *sessionRepository) FindByFamilyID(ctx context.Context, familyID string) ([]models.Session, error) {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "sessions", "FindByFamilyID")
+	defer end()
+
+	sessions := []models.Session{}
+	cursor, err := r.col.Find(ctx, bson.M{"family_id": familyID})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// the function to mark a session as replaced by a newer one
+// the update only matches sessions that haven't been rotated yet, so two concurrent refreshes can't both win
+func (r *sessionRepository) MarkRotated(ctx context.Context, id string, replacedBy string) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "sessions", "MarkRotated")
+	defer end()
+
+	res, err := r.col.UpdateOne(ctx,
+		bson.M{"_id": id, "replaced_by": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"replaced_by": replacedBy}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrSessionRotated
+	}
+	return nil
+}
+
+// the function to record the last use of a session, the filter skips the sessions used less than every ago
+// so the authenticated requests only write once in a while
+func (r *sessionRepository) Touch(ctx context.Context, tokenID string, usedAt time.Time, every time.Duration) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "sessions", "Touch")
+	defer end()
+
+	_, err := r.col.UpdateOne(ctx,
+		bson.M{"access_token_id": tokenID, "last_used_at": bson.M{"$lt": usedAt.Add(-every)}},
+		bson.M{"$set": bson.M{"last_used_at": usedAt}},
+	)
+	return err
+}
+
+// the function to delete all the sessions of a token family
+func (r *sessionRepository) DeleteByFamilyID(ctx context.Context, familyID string) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "sessions", "DeleteByFamilyID")
+	defer end()
+
+	_, err := r.col.DeleteMany(ctx, bson.M{"family_id": familyID})
+	return err
+}
+
+// the function to delete all the sessions of a user
+func (r *sessionRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "sessions", "DeleteByUserID")
+	defer end()
+
+	_, err := r.col.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
+
+// the function to delete the sessions whose refresh token has expired
+// the ttl index does it in the background, this is for purging them right away
+func (r *sessionRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "sessions", "DeleteExpired")
+	defer end()
+
+	res, err := r.col.DeleteMany(ctx, bson.M{"refresh_token_expires": bson.M{"$lt": time.Now()}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
@@ -5,15 +5,26 @@ import (
 	"errors"
 
 	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/tracing"
+	"github.com/ayehia0/org/pkg/utils"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // the repository package contains the database operations for the user model
 type UserRepository interface {
-	Create(ctx context.Context, user *models.User) error                 // Create a new user
-	FindByEmail(ctx context.Context, email string) (*models.User, error) // Find a user by email
-	FindByID(ctx context.Context, id string) (*models.User, error)       // Find a user by id
+	Create(ctx context.Context, user *models.User) error                  // Create a new user and set its id
+	FindByEmail(ctx context.Context, email string) (*models.User, error)  // Find a user by email
+	FindByID(ctx context.Context, id string) (*models.User, error)        // Find a user by id
+	UpdateMFA(ctx context.Context, id string, mfa *models.MFA) error      // Replace the two factor authentication settings of a user
+	UseTOTPStep(ctx context.Context, id string, step int64) error         // Record the step of an accepted code, ErrCodeUsed if this step (or a later one) has been used
+	UseRecoveryCode(ctx context.Context, id string, hashed string) error  // Remove a (hashed) recovery code, ErrCodeUsed if it has already been removed
+	UpdatePassword(ctx context.Context, id string, password string) error // Replace the (hashed) password of a user
+	MarkEmailVerified(ctx context.Context, id string) error               // Mark the email of a user as verified
+	UpdateName(ctx context.Context, id string, name string) error         // Change the name of a user
+	SetDisabled(ctx context.Context, id string, disabled bool) error      // Disable or enable a user
+	Delete(ctx context.Context, id string) error                          // Delete a user
 }
 
 // create a new user repository
@@ -27,23 +38,36 @@ type userRepository struct {
 }
 
 // the function to create a new user
+// the email is unique thanks to the index created by the migrations, so two concurrent signups can't both win
 func (r *userRepository) Create(ctx context.Context, user *models.User) error {
-	// make sure that the email is unique
-	_, err := r.FindByEmail(ctx, user.Email)
-	if err == nil {
-		return errors.New("email already exists")
+	ctx, end := tracing.StartDB(ctx, "mongodb", "users", "Create")
+	defer end()
+
+	res, err := r.col.InsertOne(ctx, user)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrEmailExists
+		}
+		return err
 	}
-	_, err = r.col.InsertOne(ctx, user)
-	return err
+	id, ok := res.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return errors.New("error converting id to string")
+	}
+	user.ID = id.Hex()
+	return nil
 }
 
 // the function to find a user by email
 func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "users", "FindByEmail")
+	defer end()
+
 	var user models.User
 	err := r.col.FindOne(ctx, bson.M{"email": email}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -52,13 +76,177 @@ func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models
 
 // the function to find a user by id
 func (r *userRepository) FindByID(ctx context.Context, id string) (*models.User, error) {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "users", "FindByID")
+	defer end()
+
 	var user models.User
-	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&user)
+
+	objectID, err := utils.StringToObjectID(id)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	err = r.col.FindOne(ctx, bson.M{"_id": objectID}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
 	return &user, err
 }
+
+// the function to update the two factor authentication settings of a user
+func (r *userRepository) UpdateMFA(ctx context.Context, id string, mfa *models.MFA) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "users", "UpdateMFA")
+	defer end()
+
+	objectID, err := utils.StringToObjectID(id)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	res, err := r.col.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"mfa": mfa}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// the function to record the time step of an accepted code
+// the filter only matches when the step is later than the recorded one, so a code can't be accepted twice
+func (r *userRepository) UseTOTPStep(ctx context.Context, id string, step int64) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "users", "UseTOTPStep")
+	defer end()
+
+	objectID, err := utils.StringToObjectID(id)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	filter := bson.M{"_id": objectID, "last_totp_step": bson.M{"$not": bson.M{"$gte": step}}}
+	res, err := r.col.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"last_totp_step": step}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrCodeUsed
+	}
+	return nil
+}
+
+// the function to remove a used recovery code
+// the filter only matches while the code is there, so two concurrent logins can't both use it
+func (r *userRepository) UseRecoveryCode(ctx context.Context, id string, hashed string) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "users", "UseRecoveryCode")
+	defer end()
+
+	objectID, err := utils.StringToObjectID(id)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	filter := bson.M{"_id": objectID, "mfa.recovery_codes": hashed}
+	res, err := r.col.UpdateOne(ctx, filter, bson.M{"$pull": bson.M{"mfa.recovery_codes": hashed}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrCodeUsed
+	}
+	return nil
+}
+
+// the function to update the password of a user, the password must be already hashed
+func (r *userRepository) UpdatePassword(ctx context.Context, id string, password string) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "users", "UpdatePassword")
+	defer end()
+
+	objectID, err := utils.StringToObjectID(id)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	res, err := r.col.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"password": password}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// the function to mark the email of a user as verified
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id string) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "users", "MarkEmailVerified")
+	defer end()
+
+	objectID, err := utils.StringToObjectID(id)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	res, err := r.col.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"email_verified": true}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// the function to change the name of a user
+func (r *userRepository) UpdateName(ctx context.Context, id string, name string) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "users", "UpdateName")
+	defer end()
+
+	objectID, err := utils.StringToObjectID(id)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	res, err := r.col.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"name": name}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// the function to disable or enable a user
+func (r *userRepository) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "users", "SetDisabled")
+	defer end()
+
+	objectID, err := utils.StringToObjectID(id)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	res, err := r.col.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"disabled": disabled}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// the function to delete a user
+func (r *userRepository) Delete(ctx context.Context, id string) error {
+	ctx, end := tracing.StartDB(ctx, "mongodb", "users", "Delete")
+	defer end()
+
+	objectID, err := utils.StringToObjectID(id)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	res, err := r.col.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ayehia0/org/pkg/database/mongodb"
+	"github.com/ayehia0/org/pkg/database/postgres"
+	"github.com/ayehia0/org/pkg/database/redis"
+	"github.com/ayehia0/org/pkg/utils"
+)
+
+// the postgres driver, the sessions are cached in redis
+type postgresDriver struct {
+	conn       *postgres.PostgresConn
+	redisConn  *redis.RedisConn
+	dbStore    *mongodb.DBStore
+	redisStore *redis.RedisStore
+}
+
+// connect to postgres and redis
+func openPostgres(dbConfig *utils.DatabaseConfig, redisConfig *utils.RedisConfig) (Driver, error) {
+	conn, err := postgres.NewPostgresConn(dbConfig.Host, dbConfig.Port, dbConfig.Database, dbConfig.Username, dbConfig.Password, dbConfig.SSLMode)
+	if err != nil {
+		return nil, err
+	}
+
+	redisDb := 0 // the default db
+	redisConn, err := redis.NewRedisConn(redisConfig.Host, redisConfig.Port, redisConfig.Password, redisDb)
+	if err != nil {
+		conn.DB.Close()
+		return nil, err
+	}
+
+	return &postgresDriver{
+		conn:       conn,
+		redisConn:  redisConn,
+		dbStore:    postgres.NewStore(conn),
+		redisStore: redis.NewStore(redisConn),
+	}, nil
+}
+
+func (d *postgresDriver) DBStore() *mongodb.DBStore {
+	return d.dbStore
+}
+
+func (d *postgresDriver) RedisStore() *redis.RedisStore {
+	return d.redisStore
+}
+
+func (d *postgresDriver) Migrate(ctx context.Context) (int, error) {
+	return postgres.Migrate(ctx, d.conn)
+}
+
+func (d *postgresDriver) Close(ctx context.Context) error {
+	return errors.Join(
+		d.conn.DB.Close(),
+		d.redisConn.Client.Close(),
+	)
+}
+
+func (d *postgresDriver) HealthChecks() map[string]HealthCheck {
+	return map[string]HealthCheck{
+		"postgres": func(ctx context.Context) error {
+			return d.conn.DB.PingContext(ctx)
+		},
+		"redis": func(ctx context.Context) error {
+			return d.redisConn.Client.Ping(ctx).Err()
+		},
+	}
+}
@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+
+	_ "github.com/lib/pq"
+)
+
+// setup the postgres connection
+type PostgresConn struct {
+	DB *sql.DB
+}
+
+// the function to create a new postgres connection
+func NewPostgresConn(host string, port int, databaseName, username, password, sslMode string) (*PostgresConn, error) {
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(username, password),
+		Host:     fmt.Sprintf("%s:%d", host, port),
+		Path:     databaseName,
+		RawQuery: url.Values{"sslmode": {sslMode}}.Encode(),
+	}
+
+	db, err := sql.Open("postgres", dsn.String())
+	if err != nil {
+		return nil, err
+	}
+
+	// check the connection
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &PostgresConn{DB: db}, nil
+}
@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/database/mongodb/repository"
+)
+
+// the columns read into an invitation
+const invitationColumns = `id, organization_id, organization_name, email, user_id, access_level, invited_by,
+	status, expires_at, created_at, updated_at`
+
+// the invitation repository struct
+type invitationRepository struct {
+	db *sql.DB
+}
+
+// create a new invitation repository
+func NewInvitationRepository(db *sql.DB) repository.InvitationRepository {
+	return &invitationRepository{db: db}
+}
+
+// read an invitation from a row
+func scanInvitation(row scanner) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := row.Scan(&invitation.ID, &invitation.OrganizationID, &invitation.OrganizationName, &invitation.Email,
+		&invitation.UserID, &invitation.AccessLevel, &invitation.InvitedBy, &invitation.Status,
+		&invitation.ExpiresAt, &invitation.CreatedAt, &invitation.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrInvitationNotFound
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// the function to create a new invitation
+func (r *invitationRepository) Create(ctx context.Context, invitation *models.Invitation) (string, error) {
+	id := newID()
+	_, err := r.db.ExecContext(ctx, `INSERT INTO invitations (`+invitationColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		id, invitation.OrganizationID, invitation.OrganizationName, invitation.Email, invitation.UserID,
+		invitation.AccessLevel, invitation.InvitedBy, invitation.Status,
+		invitation.ExpiresAt, invitation.CreatedAt, invitation.UpdatedAt)
+	if err != nil {
+		if hasCode(err, foreignKeyViolation) {
+			return "", repository.ErrOrganizationNotFound
+		}
+		return "", err
+	}
+	return id, nil
+}
+
+// the function to find an invitation by id
+func (r *invitationRepository) FindByID(ctx context.Context, id string) (*models.Invitation, error) {
+	return scanInvitation(r.db.QueryRowContext(ctx, `SELECT `+invitationColumns+` FROM invitations WHERE id = $1`, id))
+}
+
+// the function to find all the pending invitations of an email
+func (r *invitationRepository) FindPendingByEmail(ctx context.Context, email string) ([]models.Invitation, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+invitationColumns+` FROM invitations WHERE email = $1 AND status = $2`,
+		email, models.InvitationStatusPending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []models.Invitation{}
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, *invitation)
+	}
+	return invitations, rows.Err()
+}
+
+// the function to check if the email already has a pending invitation to the organization
+func (r *invitationRepository) HasPending(ctx context.Context, orgID string, email string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (
+		SELECT 1 FROM invitations WHERE organization_id = $1 AND email = $2 AND status = $3 AND expires_at > $4
+	)`, orgID, email, models.InvitationStatusPending, time.Now()).Scan(&exists)
+	return exists, err
+}
+
+// the function to change the status of an invitation
+func (r *invitationRepository) UpdateStatus(ctx context.Context, id string, status string) error {
+	return execOne(ctx, r.db, repository.ErrInvitationNotFound,
+		`UPDATE invitations SET status = $2, updated_at = $3 WHERE id = $1`, id, status, time.Now())
+}
+
+// the function to link the pending invitations sent to an email before signing up to the user
+func (r *invitationRepository) AttachUser(ctx context.Context, email string, userID string) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE invitations SET user_id = $3, updated_at = $4 WHERE email = $1 AND status = $2`,
+		email, models.InvitationStatusPending, userID, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
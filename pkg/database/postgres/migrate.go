@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the schema migrations, each file is named <version>_<name>.sql and is applied once in a transaction
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// the key of the advisory lock taken while migrating, so two instances starting together don't both migrate
+// the lock is released with the connection of a dead instance, the others wait for it up to migrationLockWait
+const (
+	migrationLock     = 7231001
+	migrationLockWait = 10 * time.Minute
+)
+
+// a versioned change of the schema
+type migration struct {
+	version int
+	name    string
+	query   string
+}
+
+// read the migrations sorted by version
+func loadMigrations() ([]migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := []migration{}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		query, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration{version: version, name: name, query: string(query)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// the function to bring the schema up to date, returns the number of applied migrations
+func Migrate(ctx context.Context, conn *PostgresConn) (int, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return 0, err
+	}
+
+	// the lock belongs to the session, so everything runs on the same connection
+	c, err := conn.DB.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+
+	// the callers usually have no deadline, the wait is bounded anyway
+	lockCtx, cancel := context.WithTimeout(ctx, migrationLockWait)
+	defer cancel()
+	if _, err := c.ExecContext(lockCtx, "SELECT pg_advisory_lock($1)", migrationLock); err != nil {
+		if lockCtx.Err() != nil {
+			return 0, errors.New("timed out waiting for the migration lock")
+		}
+		return 0, err
+	}
+	defer c.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLock)
+
+	_, err = c.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return 0, err
+	}
+
+	// the versions already applied
+	rows, err := c.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return 0, err
+	}
+	applied := map[int]bool{}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		applied[version] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+
+		tx, err := c.BeginTx(ctx, nil)
+		if err != nil {
+			return count, err
+		}
+		if _, err := tx.ExecContext(ctx, m.query); err != nil {
+			tx.Rollback()
+			return count, fmt.Errorf("migration %s: %w", m.name, err)
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", m.version, m.name); err != nil {
+			tx.Rollback()
+			return count, err
+		}
+		if err := tx.Commit(); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
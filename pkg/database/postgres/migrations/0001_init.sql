@@ -0,0 +1,70 @@
+CREATE TABLE users (
+    id                 TEXT PRIMARY KEY,
+    name               TEXT NOT NULL,
+    email              TEXT NOT NULL UNIQUE,
+    password           TEXT NOT NULL,
+    email_verified     BOOLEAN NOT NULL DEFAULT FALSE,
+    mfa_enabled        BOOLEAN NOT NULL DEFAULT FALSE,
+    mfa_secret         TEXT NOT NULL DEFAULT '',
+    mfa_recovery_codes TEXT[] NOT NULL DEFAULT '{}'
+);
+
+CREATE TABLE organizations (
+    id                  TEXT PRIMARY KEY,
+    name                TEXT NOT NULL,
+    description         TEXT NOT NULL DEFAULT '',
+    creator             TEXT NOT NULL,
+    transfer_from       TEXT,
+    transfer_to         TEXT,
+    transfer_expires_at TIMESTAMPTZ,
+    transfer_created_at TIMESTAMPTZ
+);
+
+CREATE INDEX organizations_creator_idx ON organizations (creator);
+
+-- the members of the organizations, their name and email are read from the users
+CREATE TABLE memberships (
+    organization_id TEXT NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    user_id         TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    access_level    TEXT NOT NULL,
+    position        BIGSERIAL,
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX memberships_user_id_idx ON memberships (user_id);
+
+CREATE TABLE invitations (
+    id                TEXT PRIMARY KEY,
+    organization_id   TEXT NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    organization_name TEXT NOT NULL,
+    email             TEXT NOT NULL,
+    user_id           TEXT NOT NULL DEFAULT '',
+    access_level      TEXT NOT NULL,
+    invited_by        TEXT NOT NULL,
+    status            TEXT NOT NULL,
+    expires_at        TIMESTAMPTZ NOT NULL,
+    created_at        TIMESTAMPTZ NOT NULL,
+    updated_at        TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX invitations_email_status_idx ON invitations (email, status);
+
+CREATE TABLE sessions (
+    id                    TEXT PRIMARY KEY,
+    access_token          TEXT NOT NULL,
+    access_token_id       TEXT NOT NULL,
+    access_token_expires  TIMESTAMPTZ NOT NULL,
+    refresh_token         TEXT NOT NULL,
+    refresh_token_expires TIMESTAMPTZ NOT NULL,
+    user_id               TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    family_id             TEXT NOT NULL,
+    replaced_by           TEXT NOT NULL DEFAULT '',
+    user_agent            TEXT NOT NULL DEFAULT '',
+    ip                    TEXT NOT NULL DEFAULT '',
+    created_at            TIMESTAMPTZ NOT NULL,
+    last_used_at          TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
+CREATE INDEX sessions_access_token_id_idx ON sessions (access_token_id);
+CREATE INDEX sessions_family_id_idx ON sessions (family_id);
@@ -0,0 +1,332 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/database/mongodb/repository"
+	"github.com/lib/pq"
+)
+
+// the columns read into an organization, the members are loaded separately
+const organizationColumns = `o.id, o.name, o.description, o.creator,
+	o.transfer_from, o.transfer_to, o.transfer_expires_at, o.transfer_created_at`
+
+// the condition matching the organizations a user created or is a member of, the user id is $1
+const memberCondition = `(o.creator = $1 OR EXISTS (
+	SELECT 1 FROM memberships m WHERE m.organization_id = o.id AND m.user_id = $1
+))`
+
+// the escaping of the wildcards in a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// the organization repository struct
+type organizationRepository struct {
+	db *sql.DB
+}
+
+// create a new organization repository
+func NewOrganizationRepository(db *sql.DB) repository.OrganizationRepository {
+	return &organizationRepository{db: db}
+}
+
+// read an organization from a row
+func scanOrganization(row scanner) (*models.Organization, error) {
+	var org models.Organization
+	var from, to sql.NullString
+	var expiresAt, createdAt sql.NullTime
+	err := row.Scan(&org.ID, &org.Name, &org.Desc, &org.Creator, &from, &to, &expiresAt, &createdAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+
+	if from.Valid {
+		org.PendingTransfer = &models.OwnershipTransfer{
+			From:      from.String,
+			To:        to.String,
+			ExpiresAt: expiresAt.Time,
+			CreatedAt: createdAt.Time,
+		}
+	}
+	org.Members = []models.Member{}
+	return &org, nil
+}
+
+// read all the organizations of a query with their members
+func (r *organizationRepository) query(ctx context.Context, query string, args ...any) ([]models.Organization, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orgs := []models.Organization{}
+	for rows.Next() {
+		org, err := scanOrganization(rows)
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, *org)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadMembers(ctx, orgs); err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// load the members of the organizations in a single query, in the order they joined
+func (r *organizationRepository) loadMembers(ctx context.Context, orgs []models.Organization) error {
+	if len(orgs) == 0 {
+		return nil
+	}
+
+	index := map[string]*models.Organization{}
+	ids := make([]string, 0, len(orgs))
+	for i := range orgs {
+		index[orgs[i].ID] = &orgs[i]
+		ids = append(ids, orgs[i].ID)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT m.organization_id, u.id, u.name, u.email, m.access_level
+		FROM memberships m JOIN users u ON u.id = m.user_id
+		WHERE m.organization_id = ANY($1)
+		ORDER BY m.position`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orgID string
+		var member models.Member
+		if err := rows.Scan(&orgID, &member.ID, &member.Name, &member.Email, &member.AccessLevel); err != nil {
+			return err
+		}
+		org := index[orgID]
+		org.Members = append(org.Members, member)
+	}
+	return rows.Err()
+}
+
+// the function to create a new organization with its members
+func (r *organizationRepository) Create(ctx context.Context, org *models.Organization) (string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	id := newID()
+	_, err = tx.ExecContext(ctx, `INSERT INTO organizations (id, name, description, creator) VALUES ($1, $2, $3, $4)`,
+		id, org.Name, org.Desc, org.Creator)
+	if err != nil {
+		return "", err
+	}
+
+	for _, member := range org.Members {
+		_, err = tx.ExecContext(ctx, `INSERT INTO memberships (organization_id, user_id, access_level) VALUES ($1, $2, $3)`,
+			id, member.ID, member.AccessLevel)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return id, tx.Commit()
+}
+
+// the function to find an organization by id
+func (r *organizationRepository) FindByID(ctx context.Context, id string) (*models.Organization, error) {
+	org, err := scanOrganization(r.db.QueryRowContext(ctx, `SELECT `+organizationColumns+` FROM organizations o WHERE o.id = $1`, id))
+	if err != nil {
+		return nil, err
+	}
+
+	orgs := []models.Organization{*org}
+	if err := r.loadMembers(ctx, orgs); err != nil {
+		return nil, err
+	}
+	return &orgs[0], nil
+}
+
+// the function to update an organization
+// update only the given fields
+func (r *organizationRepository) Update(ctx context.Context, org *models.Organization) (*models.Organization, error) {
+	err := execOne(ctx, r.db, repository.ErrOrganizationNotFound,
+		`UPDATE organizations SET name = $2, description = $3 WHERE id = $1`, org.ID, org.Name, org.Desc)
+	if err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// the function to delete an organization, the memberships and the invitations are deleted with it
+func (r *organizationRepository) Delete(ctx context.Context, id string) error {
+	return execOne(ctx, r.db, repository.ErrOrganizationNotFound, `DELETE FROM organizations WHERE id = $1`, id)
+}
+
+// the function to add a member to an organization
+func (r *organizationRepository) AddMember(ctx context.Context, orgID string, member *models.Member) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO memberships (organization_id, user_id, access_level) VALUES ($1, $2, $3)`,
+		orgID, member.ID, member.AccessLevel)
+	if err != nil {
+		if hasCode(err, foreignKeyViolation) {
+			return repository.ErrOrganizationNotFound
+		}
+		if hasCode(err, uniqueViolation) {
+			return repository.ErrMemberExists
+		}
+	}
+	return err
+}
+
+func (r *organizationRepository) FindAll(ctx context.Context) ([]models.Organization, error) {
+	return r.query(ctx, `SELECT `+organizationColumns+` FROM organizations o ORDER BY o.id`)
+}
+
+func (r *organizationRepository) IsUserInOrganization(ctx context.Context, orgID string, email string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (
+		SELECT 1 FROM memberships m JOIN users u ON u.id = m.user_id WHERE m.organization_id = $1 AND u.email = $2
+	)`, orgID, email).Scan(&exists)
+	return exists, err
+}
+
+// the function to find the organizations a user created or is a member of
+func (r *organizationRepository) FindByMember(ctx context.Context, userID string) ([]models.Organization, error) {
+	return r.query(ctx, `SELECT `+organizationColumns+` FROM organizations o WHERE `+memberCondition+` ORDER BY o.id`, userID)
+}
+
+// the function to list the organizations of a member page by page
+// the pagination is based on a cursor (the sort key and the id of the last organization) so the pages are stable
+// the names are compared byte by byte (the C collation) to sort them the same way as mongodb
+func (r *organizationRepository) ListByMember(ctx context.Context, opts repository.OrganizationListOptions) ([]models.Organization, string, error) {
+	conditions := []string{memberCondition}
+	args := []any{opts.MemberID}
+
+	// add an argument and return its placeholder
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if opts.Name != "" {
+		conditions = append(conditions, "o.name ILIKE "+arg("%"+likeEscaper.Replace(opts.Name)+"%"))
+	}
+
+	direction, operator := "ASC", ">"
+	if opts.Descending {
+		direction, operator = "DESC", "<"
+	}
+
+	// continue right after the last organization of the previous page
+	if opts.Cursor != "" {
+		cursor, err := repository.DecodeCursor(opts.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		if opts.SortBy == repository.SortByName {
+			conditions = append(conditions, fmt.Sprintf(`(o.name COLLATE "C", o.id COLLATE "C") %s (%s, %s)`,
+				operator, arg(cursor.Name), arg(cursor.ID)))
+		} else {
+			conditions = append(conditions, fmt.Sprintf(`o.id COLLATE "C" %s %s`, operator, arg(cursor.ID)))
+		}
+	}
+
+	// the ids are increasing with the creation time
+	order := fmt.Sprintf(`o.id COLLATE "C" %s`, direction)
+	if opts.SortBy == repository.SortByName {
+		order = fmt.Sprintf(`o.name COLLATE "C" %s, %s`, direction, order)
+	}
+
+	// fetch one more organization to know if there is a next page
+	query := fmt.Sprintf(`SELECT %s FROM organizations o WHERE %s ORDER BY %s LIMIT %s`,
+		organizationColumns, strings.Join(conditions, " AND "), order, arg(opts.Limit+1))
+	orgs, err := r.query(ctx, query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(orgs) <= opts.Limit {
+		return orgs, "", nil
+	}
+
+	orgs = orgs[:opts.Limit]
+	last := orgs[len(orgs)-1]
+	next := repository.ListCursor{ID: last.ID}
+	if opts.SortBy == repository.SortByName {
+		next.Name = last.Name
+	}
+	return orgs, repository.EncodeCursor(next), nil
+}
+
+// the function to change the access level of a member
+func (r *organizationRepository) UpdateMemberAccessLevel(ctx context.Context, orgID string, userID string, accessLevel string) error {
+	return execOne(ctx, r.db, repository.ErrMemberNotFound,
+		`UPDATE memberships SET access_level = $3 WHERE organization_id = $1 AND user_id = $2`, orgID, userID, accessLevel)
+}
+
+// the names of the members are read from the users, so there is nothing to copy
+func (r *organizationRepository) UpdateMemberName(ctx context.Context, userID string, name string) error {
+	return nil
+}
+
+// the function to remove a member from an organization
+func (r *organizationRepository) RemoveMember(ctx context.Context, orgID string, userID string) error {
+	return execOne(ctx, r.db, repository.ErrMemberNotFound,
+		`DELETE FROM memberships WHERE organization_id = $1 AND user_id = $2`, orgID, userID)
+}
+
+// the function to remove a user from all the organizations
+func (r *organizationRepository) RemoveMemberFromAll(ctx context.Context, userID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM memberships WHERE user_id = $1`, userID)
+	return err
+}
+
+// the function to change the creator of an organization
+func (r *organizationRepository) SetCreator(ctx context.Context, orgID string, userID string) error {
+	return execOne(ctx, r.db, repository.ErrOrganizationNotFound, `UPDATE organizations SET creator = $2 WHERE id = $1`, orgID, userID)
+}
+
+// the function to save a pending ownership transfer, it replaces the previous one if any
+func (r *organizationRepository) ProposeTransfer(ctx context.Context, orgID string, transfer *models.OwnershipTransfer) error {
+	return execOne(ctx, r.db, repository.ErrOrganizationNotFound, `UPDATE organizations
+		SET transfer_from = $2, transfer_to = $3, transfer_expires_at = $4, transfer_created_at = $5
+		WHERE id = $1`, orgID, transfer.From, transfer.To, transfer.ExpiresAt, transfer.CreatedAt)
+}
+
+// the function to drop the pending ownership transfer
+func (r *organizationRepository) ClearTransfer(ctx context.Context, orgID string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE organizations
+		SET transfer_from = NULL, transfer_to = NULL, transfer_expires_at = NULL, transfer_created_at = NULL
+		WHERE id = $1`, orgID)
+	return err
+}
+
+// the function to complete the ownership transfer, the organization and the access levels change in a single statement
+// so the organization is never seen half transferred, it only matches if the transfer is still pending and its proposer is still an owner
+func (r *organizationRepository) CompleteTransfer(ctx context.Context, orgID string, from string, to string) error {
+	return execOne(ctx, r.db, repository.ErrTransferNotFound, `WITH transferred AS (
+			UPDATE organizations o
+			SET creator = $3, transfer_from = NULL, transfer_to = NULL, transfer_expires_at = NULL, transfer_created_at = NULL
+			WHERE o.id = $1 AND o.transfer_from = $2 AND o.transfer_to = $3
+				AND EXISTS (SELECT 1 FROM memberships m WHERE m.organization_id = o.id AND m.user_id = $3)
+				AND (o.creator = $2 OR EXISTS (
+					SELECT 1 FROM memberships m WHERE m.organization_id = o.id AND m.user_id = $2 AND m.access_level = $4))
+			RETURNING o.id
+		)
+		UPDATE memberships
+		SET access_level = CASE WHEN user_id = $3 THEN $4 ELSE $5 END
+		WHERE organization_id IN (SELECT id FROM transferred) AND user_id IN ($2, $3)`,
+		orgID, from, to, models.AccessLevelOwner, models.AccessLevelAdmin)
+}
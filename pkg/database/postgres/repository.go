@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/ayehia0/org/pkg/database/mongodb"
+	"github.com/lib/pq"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// the postgres error codes we need to recognize
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// here we gather all the repositories, they implement the same interfaces as the mongodb ones
+func NewStore(conn *PostgresConn) *mongodb.DBStore {
+	return &mongodb.DBStore{
+		UserRepository:         NewUserRepository(conn.DB),
+		SessionRepository:      NewSessionRepository(conn.DB),
+		OrganizationRepository: NewOrganizationRepository(conn.DB),
+		InvitationRepository:   NewInvitationRepository(conn.DB),
+	}
+}
+
+// the ids look like the mongodb ones, they are increasing with the creation time which the listing relies on
+func newID() string {
+	return primitive.NewObjectID().Hex()
+}
+
+// check the postgres error code of an error
+func hasCode(err error, code string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && string(pqErr.Code) == code
+}
+
+// the scanner of a single row or of a row of a result
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// execute a statement that must change at least one row, notFound is returned otherwise
+func execOne(ctx context.Context, db *sql.DB, notFound error, query string, args ...any) error {
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return notFound
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/database/mongodb/repository"
+)
+
+// the columns read into a session
+const sessionColumns = `id, access_token, access_token_id, access_token_expires, refresh_token, refresh_token_expires,
+	user_id, family_id, replaced_by, user_agent, ip, created_at, last_used_at`
+
+// the session repository struct
+type sessionRepository struct {
+	db *sql.DB
+}
+
+// create a new session repository
+func NewSessionRepository(db *sql.DB) repository.SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+// read a session from a row
+func scanSession(row scanner) (*models.Session, error) {
+	var session models.Session
+	err := row.Scan(&session.ID, &session.AccessToken, &session.AccessTokenID, &session.AccessTokenExpires,
+		&session.RefreshToken, &session.RefreshTokenExpires, &session.UserID, &session.FamilyID,
+		&session.ReplacedBy, &session.UserAgent, &session.IP, &session.CreatedAt, &session.LastUsedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// read all the sessions of a query
+func (r *sessionRepository) query(ctx context.Context, query string, args ...any) ([]models.Session, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, rows.Err()
+}
+
+// the function to create a new session
+func (r *sessionRepository) Create(ctx context.Context, session *models.Session) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO sessions (`+sessionColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		session.ID, session.AccessToken, session.AccessTokenID, session.AccessTokenExpires,
+		session.RefreshToken, session.RefreshTokenExpires, session.UserID, session.FamilyID,
+		session.ReplacedBy, session.UserAgent, session.IP, session.CreatedAt, session.LastUsedAt)
+	if hasCode(err, uniqueViolation) {
+		return repository.ErrSessionExists
+	}
+	return err
+}
+
+// the function to find a session by id
+func (r *sessionRepository) FindByID(ctx context.Context, id string) (*models.Session, error) {
+	return scanSession(r.db.QueryRowContext(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE id = $1`, id))
+}
+
+// the function to find the active sessions of a user, the rotated ones are only kept to detect reuse
+func (r *sessionRepository) FindByUserID(ctx context.Context, userID string) ([]models.Session, error) {
+	return r.query(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE user_id = $1 AND replaced_by = ''`, userID)
+}
+
+// the function to find all the sessions of a user, the rotated ones included
+func (r *sessionRepository) FindAllByUserID(ctx context.Context, userID string) ([]models.Session, error) {
+	return r.query(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE user_id = $1`, userID)
+}
+
+// the function to find the session of an access token
+func (r *sessionRepository) FindByAccessTokenID(ctx context.Context, tokenID string) (*models.Session, error) {
+	return scanSession(r.db.QueryRowContext(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE access_token_id = $1`, tokenID))
+}
+
+// the function to find all the sessions that belong to the same token family
+func (r *sessionRepository) FindByFamilyID(ctx context.Context, familyID string) ([]models.Session, error) {
+	return r.query(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE family_id = $1`, familyID)
+}
+
+// the function to mark a session as replaced by a newer one
+// the update only matches sessions that haven't been rotated yet, so two concurrent refreshes can't both win
+func (r *sessionRepository) MarkRotated(ctx context.Context, id string, replacedBy string) error {
+	return execOne(ctx, r.db, repository.ErrSessionRotated,
+		`UPDATE sessions SET replaced_by = $2 WHERE id = $1 AND replaced_by = ''`, id, replacedBy)
+}
+
+// the function to record the last use of a session, the sessions used less than every ago are skipped
+func (r *sessionRepository) Touch(ctx context.Context, tokenID string, usedAt time.Time, every time.Duration) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE sessions SET last_used_at = $2 WHERE access_token_id = $1 AND last_used_at < $3`, tokenID, usedAt, usedAt.Add(-every))
+	return err
+}
+
+// the function to delete a session
+func (r *sessionRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE id = $1`, id)
+	return err
+}
+
+// the function to delete all the sessions of a token family
+func (r *sessionRepository) DeleteByFamilyID(ctx context.Context, familyID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE family_id = $1`, familyID)
+	return err
+}
+
+// the function to delete all the sessions of a user
+func (r *sessionRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = $1`, userID)
+	return err
+}
+
+// the function to delete the sessions whose refresh token has expired
+func (r *sessionRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE refresh_token_expires < $1`, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// the last use is only written when the recorded one is older than the interval
+func TestSessionTouchIsThrottled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	sessions := NewSessionRepository(db)
+
+	usedAt := time.Now()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE sessions SET last_used_at = $2 WHERE access_token_id = $1 AND last_used_at < $3")).
+		WithArgs("token", usedAt, usedAt.Add(-time.Minute)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	if err := sessions.Touch(context.Background(), "token", usedAt, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/database/mongodb/repository"
+	"github.com/lib/pq"
+)
+
+// the columns read into a user
+const userColumns = "id, name, email, password, email_verified, mfa_enabled, mfa_secret, mfa_recovery_codes, disabled"
+
+// the user repository struct
+type userRepository struct {
+	db *sql.DB
+}
+
+// create a new user repository
+func NewUserRepository(db *sql.DB) repository.UserRepository {
+	return &userRepository{db: db}
+}
+
+// read a user from a row
+func scanUser(row scanner) (*models.User, error) {
+	var user models.User
+	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.EmailVerified,
+		&user.MFA.Enabled, &user.MFA.Secret, pq.Array(&user.MFA.RecoveryCodes), &user.Disabled)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// the recovery codes written to the table, pq writes a nil array as NULL which the column doesn't accept
+func recoveryCodes(codes []string) pq.StringArray {
+	if codes == nil {
+		return pq.StringArray{}
+	}
+	return pq.StringArray(codes)
+}
+
+// the function to create a new user, the email is unique in the table
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	id := newID()
+	_, err := r.db.ExecContext(ctx, `INSERT INTO users (`+userColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		id, user.Name, user.Email, user.Password, user.EmailVerified,
+		user.MFA.Enabled, user.MFA.Secret, recoveryCodes(user.MFA.RecoveryCodes), user.Disabled)
+	if err != nil {
+		if hasCode(err, uniqueViolation) {
+			return repository.ErrEmailExists
+		}
+		return err
+	}
+	user.ID = id
+	return nil
+}
+
+// the function to find a user by email
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE email = $1`, email))
+}
+
+// the function to find a user by id
+func (r *userRepository) FindByID(ctx context.Context, id string) (*models.User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+}
+
+// the function to update the two factor authentication settings of a user
+func (r *userRepository) UpdateMFA(ctx context.Context, id string, mfa *models.MFA) error {
+	return execOne(ctx, r.db, repository.ErrUserNotFound,
+		`UPDATE users SET mfa_enabled = $2, mfa_secret = $3, mfa_recovery_codes = $4 WHERE id = $1`,
+		id, mfa.Enabled, mfa.Secret, recoveryCodes(mfa.RecoveryCodes))
+}
+
+// the function to record the time step of an accepted code, only a later step than the recorded one is accepted
+func (r *userRepository) UseTOTPStep(ctx context.Context, id string, step int64) error {
+	return execOne(ctx, r.db, repository.ErrCodeUsed,
+		`UPDATE users SET last_totp_step = $2 WHERE id = $1 AND last_totp_step < $2`, id, step)
+}
+
+// the function to remove a used recovery code, only a code that is still there is removed
+func (r *userRepository) UseRecoveryCode(ctx context.Context, id string, hashed string) error {
+	return execOne(ctx, r.db, repository.ErrCodeUsed,
+		`UPDATE users SET mfa_recovery_codes = array_remove(mfa_recovery_codes, $2) WHERE id = $1 AND $2 = ANY(mfa_recovery_codes)`, id, hashed)
+}
+
+// the function to update the password of a user, the password must be already hashed
+func (r *userRepository) UpdatePassword(ctx context.Context, id string, password string) error {
+	return execOne(ctx, r.db, repository.ErrUserNotFound, `UPDATE users SET password = $2 WHERE id = $1`, id, password)
+}
+
+// the function to mark the email of a user as verified
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id string) error {
+	return execOne(ctx, r.db, repository.ErrUserNotFound, `UPDATE users SET email_verified = TRUE WHERE id = $1`, id)
+}
+
+// the function to change the name of a user
+func (r *userRepository) UpdateName(ctx context.Context, id string, name string) error {
+	return execOne(ctx, r.db, repository.ErrUserNotFound, `UPDATE users SET name = $2 WHERE id = $1`, id, name)
+}
+
+// the function to disable or enable a user
+func (r *userRepository) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	return execOne(ctx, r.db, repository.ErrUserNotFound, `UPDATE users SET disabled = $2 WHERE id = $1`, id, disabled)
+}
+
+// the function to delete a user, the memberships and the sessions are deleted with him
+func (r *userRepository) Delete(ctx context.Context, id string) error {
+	return execOne(ctx, r.db, repository.ErrUserNotFound, `DELETE FROM users WHERE id = $1`, id)
+}
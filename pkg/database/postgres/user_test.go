@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/database/mongodb/repository"
+)
+
+// the users without two factor authentication have no recovery codes, the column is NOT NULL so they are written as an empty array
+func TestUserRecoveryCodesAreNeverNull(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	users := NewUserRepository(db)
+	ctx := context.Background()
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO users")).
+		WithArgs(sqlmock.AnyArg(), "name", "name@example.com", "hash", false, false, "", "{}", false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := users.Create(ctx, &models.User{Name: "name", Email: "name@example.com", Password: "hash"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// enrolling sets the secret only, disabling resets everything
+	for _, mfa := range []*models.MFA{{Secret: "secret"}, {}} {
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET mfa_enabled")).
+			WithArgs("id", false, mfa.Secret, "{}").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		if err := users.UpdateMFA(ctx, "id", mfa); err != nil {
+			t.Fatalf("update mfa: %v", err)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// the codes are used with a conditional update, nothing updated means the code has already been used
+func TestUserCodesAreUsedOnce(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	users := NewUserRepository(db)
+	ctx := context.Background()
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET last_totp_step = $2 WHERE id = $1 AND last_totp_step < $2")).
+		WithArgs("id", int64(100)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	if err := users.UseTOTPStep(ctx, "id", 100); !errors.Is(err, repository.ErrCodeUsed) {
+		t.Fatalf("use totp step: got %v, want %v", err, repository.ErrCodeUsed)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET mfa_recovery_codes = array_remove(mfa_recovery_codes, $2) WHERE id = $1 AND $2 = ANY(mfa_recovery_codes)")).
+		WithArgs("id", "hash").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := users.UseRecoveryCode(ctx, "id", "hash"); err != nil {
+		t.Fatalf("use recovery code: %v", err)
+	}
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET mfa_recovery_codes")).
+		WithArgs("id", "hash").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	if err := users.UseRecoveryCode(ctx, "id", "hash"); !errors.Is(err, repository.ErrCodeUsed) {
+		t.Fatalf("use recovery code again: got %v, want %v", err, repository.ErrCodeUsed)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
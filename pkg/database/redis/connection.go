@@ -9,8 +9,9 @@ import (
 
 // here we setup the connection to the redis database and return the connection
 
+// the client keeps a pool of connections, it is safe to share between the requests
 type RedisConn struct {
-	Conn *redis.Conn
+	Client *redis.Client
 }
 
 func NewRedisConn(host string, port int, password string, database int) (*RedisConn, error) {
@@ -22,9 +23,9 @@ func NewRedisConn(host string, port int, password string, database int) (*RedisC
 
 	_, err := client.Ping(context.Background()).Result()
 	if err != nil {
+		client.Close()
 		return nil, err
 	}
 
-	conn := client.Conn()
-	return &RedisConn{Conn: conn}, nil
+	return &RedisConn{Client: client}, nil
 }
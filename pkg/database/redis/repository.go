@@ -3,12 +3,21 @@ package redis
 import "github.com/ayehia0/org/pkg/database/redis/repository"
 
 type RedisStore struct {
-	SessionRepository repository.SessionRepository
+	SessionRepository    repository.SessionRepository
+	RevocationRepository repository.RevocationRepository
+	RateLimitRepository  repository.RateLimitRepository
+	LockoutRepository    repository.LockoutRepository
 }
 
 func NewStore(conn *RedisConn) *RedisStore {
-	session := repository.NewSessionRepository(conn.Conn)
+	session := repository.NewSessionRepository(conn.Client)
+	revocation := repository.NewRevocationRepository(conn.Client)
+	rateLimit := repository.NewRateLimitRepository(conn.Client)
+	lockout := repository.NewLockoutRepository(conn.Client)
 	return &RedisStore{
-		SessionRepository: session,
+		SessionRepository:    session,
+		RevocationRepository: revocation,
+		RateLimitRepository:  rateLimit,
+		LockoutRepository:    lockout,
 	}
 }
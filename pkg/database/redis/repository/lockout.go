@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// the prefixes of the keys used to store the failed attempts and the locks
+const (
+	failuresPrefix = "lockout:failures:"
+	lockPrefix     = "lockout:locked:"
+)
+
+// the lockout repository counts the failed attempts on an account and locks it for a while once there are too many
+type LockoutRepository interface {
+	RecordFailure(ctx context.Context, key string, window time.Duration) (int64, error) // count a failed attempt, the count is reset once the window is over
+	Lock(ctx context.Context, key string, duration time.Duration) error                 // lock the key and forget its failed attempts
+	LockedFor(ctx context.Context, key string) (time.Duration, error)                   // how long the key stays locked, zero when it isn't
+	Reset(ctx context.Context, key string) error                                        // forget the failed attempts of the key
+}
+
+type lockoutRepository struct {
+	client *redis.Client
+}
+
+func NewLockoutRepository(client *redis.Client) LockoutRepository {
+	return &lockoutRepository{client: client}
+}
+
+// the window starts with the first failure
+var countFailure = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count
+`)
+
+func (r *lockoutRepository) RecordFailure(ctx context.Context, key string, window time.Duration) (int64, error) {
+	return countFailure.Run(ctx, r.client, []string{failuresPrefix + key}, window.Milliseconds()).Int64()
+}
+
+func (r *lockoutRepository) Lock(ctx context.Context, key string, duration time.Duration) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, lockPrefix+key, 1, duration)
+		pipe.Del(ctx, failuresPrefix+key)
+		return nil
+	})
+	return err
+}
+
+func (r *lockoutRepository) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := r.client.PTTL(ctx, lockPrefix+key).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	// the key doesn't exist (-2) or has no expiration (-1)
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (r *lockoutRepository) Reset(ctx context.Context, key string) error {
+	return r.client.Del(ctx, failuresPrefix+key).Err()
+}
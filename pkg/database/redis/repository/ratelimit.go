@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// the prefix of the keys used to store the hits of the rate limits
+const rateLimitPrefix = "ratelimit:"
+
+// the rate limit repository counts the hits of a key (an ip, an email, ...) in a sliding window
+type RateLimitRepository interface {
+	// record a hit unless the limit of the window has been reached, retryAfter tells when the next hit is accepted
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (allowed bool, retryAfter time.Duration, err error)
+}
+
+type rateLimitRepository struct {
+	client *redis.Client
+}
+
+func NewRateLimitRepository(client *redis.Client) RateLimitRepository {
+	return &rateLimitRepository{client: client}
+}
+
+// the hits are the members of a sorted set scored by their time (in ms), the ones out of the window are dropped first
+// the script runs atomically so the concurrent requests can't go over the limit
+var slidingWindow = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+if redis.call('ZCARD', KEYS[1]) < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[4])
+	redis.call('PEXPIRE', KEYS[1], window)
+	return 0
+end
+
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+return tonumber(oldest[2]) + window - now
+`)
+
+func (r *rateLimitRepository) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	now := time.Now().UnixMilli()
+	wait, err := slidingWindow.Run(ctx, r.client, []string{rateLimitPrefix + key},
+		now, window.Milliseconds(), limit, uuid.NewString()).Int64()
+	if err != nil {
+		return false, 0, err
+	}
+
+	if wait <= 0 {
+		return true, 0, nil
+	}
+	return false, time.Duration(wait) * time.Millisecond, nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// the prefix of the keys used to store the revoked tokens
+const revokedTokenPrefix = "revoked:"
+
+// the revocation repository keeps a denylist of the tokens (by id) that must not be accepted anymore
+// each entry lives as long as the token it revokes, after that the token is expired anyway
+type RevocationRepository interface {
+	Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+	// revoke the token unless it already is, false means another caller revoked it first (single use tokens)
+	Claim(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error)
+}
+
+type revocationRepository struct {
+	client *redis.Client
+}
+
+func NewRevocationRepository(client *redis.Client) RevocationRepository {
+	return &revocationRepository{client: client}
+}
+
+func (r *revocationRepository) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	// the token has already expired, nothing to revoke
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return r.client.Set(ctx, revokedTokenPrefix+tokenID, 1, ttl).Err()
+}
+
+func (r *revocationRepository) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	count, err := r.client.Exists(ctx, revokedTokenPrefix+tokenID).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *revocationRepository) Claim(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error) {
+	// the token has already expired, it can't be used anyway
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return false, nil
+	}
+
+	// SET NX is atomic, only one of the concurrent callers sets the key
+	return r.client.SetNX(ctx, revokedTokenPrefix+tokenID, 1, ttl).Result()
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/ayehia0/org/pkg/database/mongodb/models"
+	"github.com/ayehia0/org/pkg/metrics"
+	"github.com/ayehia0/org/pkg/tracing"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -17,14 +19,17 @@ type SessionRepository interface {
 }
 
 type sessionRepository struct {
-	conn *redis.Conn
+	client *redis.Client
 }
 
-func NewSessionRepository(conn *redis.Conn) SessionRepository {
-	return &sessionRepository{conn: conn}
+func NewSessionRepository(client *redis.Client) SessionRepository {
+	return &sessionRepository{client: client}
 }
 
 func (r *sessionRepository) CreateSession(ctx context.Context, session *models.Session) error {
+	ctx, end := tracing.StartDB(ctx, "redis", "session_cache", "CreateSession")
+	defer end()
+
 	// add a session to the redis database
 	// convert the session to a json
 	sessionJSON, err := json.Marshal(session)
@@ -33,7 +38,7 @@ func (r *sessionRepository) CreateSession(ctx context.Context, session *models.S
 	}
 
 	// add the session to the redis database
-	err = r.conn.Set(ctx, session.RefreshToken, sessionJSON, session.RefreshTokenExpires.Sub(time.Now())).Err()
+	err = r.client.Set(ctx, session.RefreshToken, sessionJSON, session.RefreshTokenExpires.Sub(time.Now())).Err()
 	if err != nil {
 		return err
 	}
@@ -42,9 +47,17 @@ func (r *sessionRepository) CreateSession(ctx context.Context, session *models.S
 }
 
 func (r *sessionRepository) GetSessionByID(ctx context.Context, id string) (*models.Session, error) {
+	ctx, end := tracing.StartDB(ctx, "redis", "session_cache", "GetSessionByID")
+	defer end()
+
 	// get the session from the redis database
-	sessionJSON, err := r.conn.Get(ctx, id).Result()
+	sessionJSON, err := r.client.Get(ctx, id).Result()
 	if err != nil {
+		// the session isn't cached (or has been rotated)
+		if err == redis.Nil {
+			metrics.ObserveSessionCache(false)
+			return nil, nil
+		}
 		return nil, err
 	}
 
@@ -55,12 +68,16 @@ func (r *sessionRepository) GetSessionByID(ctx context.Context, id string) (*mod
 		return nil, err
 	}
 
+	metrics.ObserveSessionCache(true)
 	return &session, nil
 }
 
 func (r *sessionRepository) DeleteSession(ctx context.Context, id string) error {
+	ctx, end := tracing.StartDB(ctx, "redis", "session_cache", "DeleteSession")
+	defer end()
+
 	// delete the session from the redis database
-	err := r.conn.Del(ctx, id).Err()
+	err := r.client.Del(ctx, id).Err()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,95 @@
+package logger
+
+/*
+The logger package creates the structured logger of the application, every record is enriched from its context:
+	- request_id: the id of the request being served
+	- trace_id, span_id: the span of the request when it's traced
+The passwords, the tokens and the secrets are redacted before the records are written (see redact.go).
+*/
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/ayehia0/org/pkg/utils"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// the formats of the records
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// create the logger writing to the stdout according to the log config
+func New(config utils.LogConfig) *slog.Logger {
+	return NewWithWriter(os.Stdout, config)
+}
+
+// create the logger writing to the given writer
+func NewWithWriter(w io.Writer, config utils.LogConfig) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level:       parseLevel(config.Level),
+		ReplaceAttr: redactAttr,
+	}
+
+	var handler slog.Handler
+	if config.Format == FormatText {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(&contextHandler{Handler: handler})
+}
+
+// the level defaults to info when it's empty or unknown
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+type requestIDKey struct{}
+
+// attach the id of the request to the context
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// get the id of the request from the context, empty when there is none
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// the handler adding the request and the span of the context to the records
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		r.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
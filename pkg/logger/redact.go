@@ -0,0 +1,126 @@
+package logger
+
+import (
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
+// the value written instead of the secrets
+const Redacted = "[REDACTED]"
+
+// the keys holding secrets whatever their suffix is
+var sensitiveKeys = map[string]bool{
+	"authorization":  true,
+	"cookie":         true,
+	"set-cookie":     true,
+	"code":           true,
+	"recovery_code":  true,
+	"recovery_codes": true,
+	"otpauth_uri":    true,
+}
+
+// the suffixes of the keys holding secrets: password, new_password, refresh_token, mfa_token, jwtSecret, ...
+var sensitiveSuffixes = []string{"password", "token", "secret"}
+
+// the prefixes of the values that are secrets whatever their key is: the paseto tokens and the authorization headers
+var sensitivePrefixes = []string{"v2.local.", "bearer "}
+
+// check if the key of an attribute (or of a json field) holds a secret
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	if sensitiveKeys[key] {
+		return true
+	}
+	for _, suffix := range sensitiveSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// check if a string looks like a secret
+func isSensitiveValue(value string) bool {
+	value = strings.ToLower(value)
+	for _, prefix := range sensitivePrefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact the attributes before they are written, the structs and the maps are redacted field by field
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if isSensitiveKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		if isSensitiveValue(a.Value.String()) {
+			return slog.String(a.Key, Redacted)
+		}
+	case slog.KindAny:
+		if value, ok := redactAny(a.Value.Any()); ok {
+			return slog.Any(a.Key, value)
+		}
+	}
+	return a
+}
+
+// redact a struct, a map or a slice through its json form, ok is false when the value is left as it is
+func redactAny(value any) (any, bool) {
+	// the errors are written with their message
+	if _, isErr := value.(error); isErr {
+		return nil, false
+	}
+
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+	default:
+		return nil, false
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, false
+	}
+	return redactJSON(decoded), true
+}
+
+// walk the decoded json and redact the secrets
+func redactJSON(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if isSensitiveKey(key) {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = redactJSON(field)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactJSON(item)
+		}
+	case string:
+		if isSensitiveValue(v) {
+			return Redacted
+		}
+	}
+	return value
+}
@@ -0,0 +1,44 @@
+package mailer
+
+/*
+The mailer package is responsible for delivering the emails sent by the application (password reset, ...).
+	- smtp: the real delivery through an smtp server
+	- file: every email is written to a directory, handy for local development
+	- memory: the emails are kept in memory, handy for tests
+*/
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ayehia0/org/pkg/utils"
+)
+
+// an email to be sent
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// create the mailer configured by the driver, in production the emails have to be delivered for real
+func New(config utils.MailConfig, production bool) (Mailer, error) {
+	if production && config.Driver != "smtp" {
+		return nil, fmt.Errorf("the mail driver %q doesn't deliver the emails, production requires smtp", config.Driver)
+	}
+
+	switch config.Driver {
+	case "smtp":
+		return NewSMTPMailer(config.Host, config.Port, config.Username, config.Password, config.From), nil
+	case "file", "":
+		return NewFileMailer(config.Dir, config.From)
+	case "memory":
+		return NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unsupported mail driver: %s", config.Driver)
+	}
+}
@@ -0,0 +1,126 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ayehia0/org/pkg/utils"
+)
+
+func TestNewRequiresSMTPInProduction(t *testing.T) {
+	for _, driver := range []string{"file", "memory", ""} {
+		if _, err := New(utils.MailConfig{Driver: driver, Dir: t.TempDir()}, true); err == nil {
+			t.Errorf("the %q driver has been accepted in production", driver)
+		}
+	}
+	if _, err := New(utils.MailConfig{Driver: "smtp", Host: "localhost", Port: 25}, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(utils.MailConfig{Driver: "file", Dir: t.TempDir()}, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// a fake smtp server answering every command with ok, the data it receives is sent on the channel
+func fakeSMTPServer(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	data := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		conn.Write([]byte("220 localhost ready\r\n"))
+		var body strings.Builder
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch {
+			case inData && line == ".\r\n":
+				inData = false
+				data <- body.String()
+				conn.Write([]byte("250 queued\r\n"))
+			case inData:
+				body.WriteString(line)
+			case strings.HasPrefix(line, "EHLO"):
+				conn.Write([]byte("250 localhost\r\n"))
+			case strings.HasPrefix(line, "DATA"):
+				inData = true
+				conn.Write([]byte("354 go ahead\r\n"))
+			case strings.HasPrefix(line, "QUIT"):
+				conn.Write([]byte("221 bye\r\n"))
+				return
+			default:
+				conn.Write([]byte("250 ok\r\n"))
+			}
+		}
+	}()
+	return ln.Addr().String(), data
+}
+
+func newTestSMTPMailer(t *testing.T, addr string) Mailer {
+	t.Helper()
+	host, port, _ := net.SplitHostPort(addr)
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSMTPMailer(host, p, "", "", "no-reply@example.com")
+}
+
+func TestSMTPSend(t *testing.T) {
+	addr, data := fakeSMTPServer(t)
+	m := newTestSMTPMailer(t, addr)
+
+	err := m.Send(context.Background(), Message{To: "alice@example.com", Subject: "hello", Body: "the body"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received := <-data; !strings.Contains(received, "Subject: hello") || !strings.Contains(received, "the body") {
+		t.Fatalf("unexpected email: %q", received)
+	}
+}
+
+func TestSMTPSendFollowsTheContext(t *testing.T) {
+	// the server accepts the connection but never answers
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(5 * time.Second)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := newTestSMTPMailer(t, ln.Addr().String()).Send(ctx, Message{To: "alice@example.com"}); err == nil {
+		t.Fatal("the email has been sent to a server that doesn't answer")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("the send took %s, the deadline of the context is ignored", elapsed)
+	}
+}
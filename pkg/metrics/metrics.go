@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+/*
+The metrics package contains the prometheus collectors of the application, they are exposed on /metrics.
+	- http: the requests per route and status
+	- auth: the logins and the token refreshes
+	- storage: the session cache and the database operations
+*/
+
+// the results counted by the collectors
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	ResultReused  = "reused"
+	ResultHit     = "hit"
+	ResultMiss    = "miss"
+)
+
+// the registry of the application, the go runtime and the process are reported too
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "The number of http requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "The latency of the http requests by method, route and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_logins_total",
+		Help: "The number of login attempts by result (success or failure).",
+	}, []string{"result"})
+
+	refreshes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_token_refreshes_total",
+		Help: "The number of token refreshes by result (success, failure or reused).",
+	}, []string{"result"})
+
+	sessionCache = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "session_cache_requests_total",
+		Help: "The number of session cache lookups by result (hit or miss).",
+	}, []string{"result"})
+
+	dbDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_operation_duration_seconds",
+		Help:    "The latency of the database operations by repository and method.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"repository", "method"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		logins,
+		refreshes,
+		sessionCache,
+		dbDuration,
+	)
+}
+
+// the handler serving the metrics in the prometheus format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// count a request and its latency, the route is the pattern of the route not the path so the ids don't explode the series
+func ObserveRequest(method, route, status string, duration time.Duration) {
+	httpRequests.WithLabelValues(method, route, status).Inc()
+	httpDuration.WithLabelValues(method, route, status).Observe(duration.Seconds())
+}
+
+// count a login attempt
+func ObserveLogin(result string) {
+	logins.WithLabelValues(result).Inc()
+}
+
+// count a token refresh
+func ObserveRefresh(result string) {
+	refreshes.WithLabelValues(result).Inc()
+}
+
+// count a lookup in the session cache
+func ObserveSessionCache(hit bool) {
+	result := ResultMiss
+	if hit {
+		result = ResultHit
+	}
+	sessionCache.WithLabelValues(result).Inc()
+}
+
+// start timing a database operation, call the returned function once it's done:
+//
+//	defer metrics.ObserveDB("users", "FindByID")()
+func ObserveDB(repository, method string) func() {
+	start := time.Now()
+	return func() {
+		dbDuration.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+	}
+}
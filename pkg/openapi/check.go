@@ -0,0 +1,43 @@
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// check that the document describes exactly the routes of the router, the undocumented paths (/metrics, ...) are skipped
+// the tests of the package build the router and fail when they drift apart, so a route can't be added (or removed) without updating the table
+func Check(routes gin.RoutesInfo, undocumented ...string) error {
+	if _, err := document(); err != nil {
+		return fmt.Errorf("failed to build the openapi document: %w", err)
+	}
+
+	documented := map[string]bool{}
+	for _, route := range Routes {
+		documented[route.Method+" "+route.Path] = true
+	}
+
+	var errs []error
+	served := map[string]bool{}
+	for _, route := range routes {
+		if slices.Contains(undocumented, route.Path) {
+			continue
+		}
+		key := route.Method + " " + route.Path
+		served[key] = true
+		if !documented[key] {
+			errs = append(errs, fmt.Errorf("the route %s isn't in the openapi document", key))
+		}
+	}
+
+	for _, route := range Routes {
+		key := route.Method + " " + route.Path
+		if !served[key] {
+			errs = append(errs, fmt.Errorf("the route %s of the openapi document isn't served", key))
+		}
+	}
+	return errors.Join(errs...)
+}
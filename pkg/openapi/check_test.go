@@ -0,0 +1,87 @@
+package openapi_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ayehia0/org/pkg"
+	types "github.com/ayehia0/org/pkg/api"
+	"github.com/ayehia0/org/pkg/database"
+	"github.com/ayehia0/org/pkg/database/memory"
+	"github.com/ayehia0/org/pkg/mailer"
+	"github.com/ayehia0/org/pkg/openapi"
+	"github.com/ayehia0/org/pkg/token"
+	"github.com/ayehia0/org/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// the paths served by the router but left out of the document
+var undocumented = []string{"/metrics", openapi.DocumentPath, openapi.UIPath, openapi.AssetPath}
+
+// the router of the server on the memory driver
+func newRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	config := &utils.AppConfig{JwtSecret: "0123456789abcdef0123456789abcdef"}
+	tokenCreator, err := token.NewPasteoToken(config.JwtSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := memory.NewStore()
+	router, err := pkg.NewRouter(&types.AppC{
+		DBStore:      store.DBStore(),
+		RDBStore:     store.RedisStore(),
+		TokenCreator: tokenCreator,
+		AppConfig:    config,
+		Mailer:       mailer.NewMemoryMailer(),
+		HealthChecks: map[string]database.HealthCheck{},
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return router
+}
+
+func TestDocumentMatchesTheRoutes(t *testing.T) {
+	router := newRouter(t)
+	if err := openapi.Check(router.Routes(), undocumented...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckReportsTheDrift(t *testing.T) {
+	routes := append(newRouter(t).Routes(), gin.RouteInfo{Method: http.MethodGet, Path: "/undocumented"})
+	err := openapi.Check(routes, undocumented...)
+	if err == nil || !strings.Contains(err.Error(), "GET /undocumented") {
+		t.Fatalf("got %v, want the undocumented route to be reported", err)
+	}
+
+	err = openapi.Check(gin.RoutesInfo{}, undocumented...)
+	if err == nil || !strings.Contains(err.Error(), "isn't served") {
+		t.Fatalf("got %v, want the routes that aren't served to be reported", err)
+	}
+}
+
+func TestUIAssets(t *testing.T) {
+	router := newRouter(t)
+	for path, status := range map[string]int{
+		openapi.UIPath:                           http.StatusOK,
+		openapi.UIPath + "/swagger-ui.css":       http.StatusOK,
+		openapi.UIPath + "/swagger-ui-bundle.js": http.StatusOK,
+		openapi.UIPath + "/index.html":           http.StatusNotFound,
+		openapi.DocumentPath:                     http.StatusOK,
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != status {
+			t.Errorf("GET %s: got %d, want %d", path, rec.Code, status)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	api "github.com/ayehia0/org/pkg/api/middleware"
+	"github.com/ayehia0/org/pkg/buildinfo"
+)
+
+// the name of the security scheme of the routes requiring an access token
+const bearerAuth = "bearerAuth"
+
+// the document is built once, the routes table doesn't change while running
+var document = sync.OnceValues(Build)
+
+// build the document from the routes table, it fails when the table or the types can't be described
+func Build() (*Document, error) {
+	b := newSchemaBuilder()
+	problem := b.schemaOf(api.Problem{}, false)
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       "Organization API",
+			Description: "The errors are answered as problem details (RFC 7807), their code is stable.",
+			Version:     buildinfo.Version,
+		},
+		Tags:  Tags,
+		Paths: map[string]PathItem{},
+		Components: Components{
+			Schemas: b.schemas,
+			SecuritySchemes: map[string]SecurityScheme{
+				bearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "PASETO", Description: "The access token returned by the login"},
+			},
+		},
+	}
+
+	for _, route := range Routes {
+		path, params := pathOf(route.Path)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = PathItem{}
+			doc.Paths[path] = item
+		}
+
+		method := strings.ToLower(route.Method)
+		if _, ok := item[method]; ok {
+			b.errs = append(b.errs, fmt.Errorf("the route %s %s is documented twice", route.Method, route.Path))
+			continue
+		}
+		item[method] = b.operation(route, params, problem)
+	}
+
+	if len(b.errs) > 0 {
+		return nil, errors.Join(b.errs...)
+	}
+	return doc, nil
+}
+
+// the openapi path of a gin path (/organizations/:id -> /organizations/{id}) and its parameters
+func pathOf(ginPath string) (string, []string) {
+	segments := strings.Split(ginPath, "/")
+	params := []string{}
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "{" + name + "}"
+			params = append(params, name)
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// the id of an operation made of its method and its path: POST /organizations/:id/invite -> postOrganizationsIdInvite
+func operationID(method, ginPath string) string {
+	var id strings.Builder
+	id.WriteString(strings.ToLower(method))
+	words := strings.FieldsFunc(ginPath, func(r rune) bool {
+		return r == '/' || r == ':' || r == '-' || r == '_' || r == '.'
+	})
+	for _, word := range words {
+		id.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return id.String()
+}
+
+func (b *schemaBuilder) operation(route Route, params []string, problem *Schema) *Operation {
+	op := &Operation{
+		Tags:        []string{route.Tag},
+		Summary:     route.Summary,
+		OperationID: operationID(route.Method, route.Path),
+		Responses:   map[string]Response{},
+	}
+
+	for _, name := range params {
+		op.Parameters = append(op.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	if route.Query != nil {
+		op.Parameters = append(op.Parameters, b.parameters(route.Query)...)
+	}
+	if route.Request != nil {
+		op.RequestBody = &RequestBody{Required: true, Content: jsonContent(b.schemaOf(route.Request, true))}
+	}
+	if route.Auth {
+		op.Security = []map[string][]string{{bearerAuth: {}}}
+	}
+
+	op.Responses[strconv.Itoa(route.Status)] = b.response(route.Status, route.Response)
+	for status, value := range route.Responses {
+		op.Responses[strconv.Itoa(status)] = b.response(status, value)
+	}
+
+	for _, status := range problems(route, params) {
+		resp := Response{
+			Description: http.StatusText(status),
+			Content:     map[string]MediaType{api.ProblemContentType: {Schema: problem}},
+		}
+		if status == http.StatusTooManyRequests {
+			resp.Headers = map[string]Header{
+				"Retry-After": {Description: "The seconds to wait before retrying", Schema: &Schema{Type: "integer"}},
+			}
+		}
+		op.Responses[strconv.Itoa(status)] = resp
+	}
+	return op
+}
+
+// the response answered with the value, a OneOf is answered with one of its values
+func (b *schemaBuilder) response(status int, value any) Response {
+	resp := Response{Description: http.StatusText(status)}
+	switch v := value.(type) {
+	case nil:
+	case OneOf:
+		s := &Schema{}
+		for _, item := range v {
+			s.OneOf = append(s.OneOf, b.schemaOf(item, false))
+		}
+		resp.Content = jsonContent(s)
+	default:
+		resp.Content = jsonContent(b.schemaOf(value, false))
+	}
+	return resp
+}
+
+// the problems answered by the route, the ones implied by its kind are added to the listed ones
+func problems(route Route, params []string) []int {
+	statuses := slices.Clone(route.Problems)
+	if route.Request != nil || route.Query != nil {
+		statuses = append(statuses, http.StatusBadRequest)
+	}
+	if route.Auth {
+		statuses = append(statuses, http.StatusUnauthorized)
+	}
+	if len(params) > 0 {
+		statuses = append(statuses, http.StatusNotFound)
+	}
+	if route.RateLimited {
+		statuses = append(statuses, http.StatusTooManyRequests)
+	}
+	statuses = append(statuses, http.StatusInternalServerError)
+
+	slices.Sort(statuses)
+	return slices.Compact(statuses)
+}
+
+func jsonContent(s *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: s}}
+}
@@ -0,0 +1,71 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	api "github.com/ayehia0/org/pkg/api/middleware"
+	"github.com/ayehia0/org/pkg/apperror"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// the paths of the document, of the page browsing it and of the assets of the page
+const (
+	DocumentPath = "/openapi.json"
+	UIPath       = "/docs"
+	AssetPath    = UIPath + "/:asset"
+)
+
+// the swagger-ui assets loaded by the page, they are embedded in the binary so the page doesn't depend on a cdn
+var uiAssets = []string{"swagger-ui.css", "swagger-ui-bundle.js"}
+
+// the page loading swagger-ui, the assets are relative to the page
+var uiPage = fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<title>Organization API</title>
+	<link rel="stylesheet" href="%[1]s/swagger-ui.css">
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="%[1]s/swagger-ui-bundle.js"></script>
+	<script>
+		window.ui = SwaggerUIBundle({ url: %[2]q, dom_id: "#swagger-ui" });
+	</script>
+</body>
+</html>
+`, UIPath, DocumentPath)
+
+// the handler answering the document
+func Handler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		doc, err := document()
+		if err != nil {
+			ctx.Error(apperror.Internal("failed to build the openapi document", err))
+			return
+		}
+		ctx.JSON(http.StatusOK, doc)
+	}
+}
+
+// the handler answering the swagger-ui page
+func UIHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Data(http.StatusOK, "text/html; charset=utf-8", []byte(uiPage))
+	}
+}
+
+// the handler answering the swagger-ui assets, only the ones loaded by the page are served
+func AssetHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		asset := ctx.Param("asset")
+		if !slices.Contains(uiAssets, asset) {
+			api.Abort(ctx, api.ErrRouteNotFound)
+			return
+		}
+		ctx.FileFromFS(asset, http.FS(swaggerFiles.FS))
+	}
+}
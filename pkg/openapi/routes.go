@@ -0,0 +1,155 @@
+package openapi
+
+import (
+	"net/http"
+
+	"github.com/ayehia0/org/pkg/buildinfo"
+	"github.com/ayehia0/org/pkg/controllers"
+	"github.com/ayehia0/org/pkg/database/mongodb/models"
+)
+
+// a documented route, the path is the one given to gin (/organizations/:id) and the bodies are values of the controllers types
+// the problems answered by every route of the same kind don't have to be listed:
+//   - 400 when there is a body or a query
+//   - 401 when an access token is required
+//   - 404 when there are path parameters
+//   - 429 when the route is rate limited
+//   - 500 for all of them
+type Route struct {
+	Method      string
+	Path        string
+	Tag         string
+	Summary     string
+	Auth        bool
+	RateLimited bool
+
+	// the json body and the query parameters
+	Request any
+	Query   any
+
+	// the success response, the other responses that aren't problems (like 503 for the readiness) are in Responses
+	Status    int
+	Response  any
+	Responses map[int]any
+
+	// the other problems answered by the route
+	Problems []int
+}
+
+// the response is one of the values
+type OneOf []any
+
+// the tags grouping the routes, in the order they are shown
+var Tags = []Tag{
+	{Name: "health", Description: "The probes of the orchestrator and the build information"},
+	{Name: "auth", Description: "The accounts, the logins and the tokens"},
+	{Name: "password", Description: "The recovery of the accounts"},
+	{Name: "profile", Description: "The account of the authenticated user"},
+	{Name: "sessions", Description: "The sessions of the authenticated user"},
+	{Name: "mfa", Description: "The two factor authentication of the authenticated user"},
+	{Name: "organizations", Description: "The organizations, their members and their ownership"},
+	{Name: "invitations", Description: "The invitations to the organizations, from the invitee side"},
+}
+
+// all the routes of the api, they must match the routes of the router (see Check)
+var Routes = []Route{
+	// health
+	{Method: http.MethodGet, Path: "/healthz", Tag: "health", Summary: "Check that the server is alive", Status: http.StatusOK, Response: controllers.HealthResponse{}},
+	{Method: http.MethodGet, Path: "/readyz", Tag: "health", Summary: "Check that the dependencies are reachable", Status: http.StatusOK, Response: controllers.ReadinessResponse{},
+		Responses: map[int]any{http.StatusServiceUnavailable: controllers.ReadinessResponse{}}},
+	{Method: http.MethodGet, Path: "/version", Tag: "health", Summary: "Get the version of the running build", Status: http.StatusOK, Response: buildinfo.Info{}},
+
+	// auth
+	{Method: http.MethodPost, Path: "/signup", Tag: "auth", Summary: "Create an account", RateLimited: true,
+		Request: controllers.SignupRequest{}, Status: http.StatusOK, Response: controllers.SignupResponse{}, Problems: []int{http.StatusConflict}},
+	{Method: http.MethodPost, Path: "/login", Tag: "auth", Summary: "Log in, the second factor is asked when it's enabled", RateLimited: true,
+		Request: controllers.LoginRequest{}, Status: http.StatusOK, Response: OneOf{controllers.RefreshTokenResponse{}, controllers.MFAChallengeResponse{}},
+		Problems: []int{http.StatusUnauthorized, http.StatusForbidden}},
+	{Method: http.MethodPost, Path: "/login/mfa", Tag: "auth", Summary: "Finish the login with the second factor", RateLimited: true,
+		Request: controllers.LoginMFARequest{}, Status: http.StatusOK, Response: controllers.RefreshTokenResponse{}, Problems: []int{http.StatusUnauthorized, http.StatusForbidden}},
+	{Method: http.MethodPost, Path: "/refresh-token", Tag: "auth", Summary: "Rotate the refresh token and get a new access token", RateLimited: true,
+		Request: controllers.RefreshTokenRequest{}, Status: http.StatusOK, Response: controllers.RefreshTokenResponse{}, Problems: []int{http.StatusUnauthorized, http.StatusForbidden}},
+	{Method: http.MethodPost, Path: "/revoke-refresh-token", Tag: "auth", Summary: "Revoke a refresh token",
+		Request: controllers.RevokeRefreshTokenRequest{}, Status: http.StatusOK, Response: controllers.MessageResponse{}, Problems: []int{http.StatusUnauthorized}},
+	{Method: http.MethodPost, Path: "/verify-email", Tag: "auth", Summary: "Verify the email with the emailed token",
+		Request: controllers.VerifyEmailRequest{}, Status: http.StatusOK, Response: controllers.MessageResponse{}, Problems: []int{http.StatusUnauthorized}},
+	{Method: http.MethodPost, Path: "/verify-email/resend", Tag: "auth", Summary: "Email a new verification token",
+		Request: controllers.ResendVerificationRequest{}, Status: http.StatusOK, Response: controllers.MessageResponse{}},
+	{Method: http.MethodPost, Path: "/logout", Tag: "auth", Summary: "Revoke the access token of the request", Auth: true,
+		Status: http.StatusOK, Response: controllers.MessageResponse{}},
+
+	// password
+	{Method: http.MethodPost, Path: "/password/forgot", Tag: "password", Summary: "Email a password reset token",
+		Request: controllers.ForgotPasswordRequest{}, Status: http.StatusOK, Response: controllers.MessageResponse{}},
+	{Method: http.MethodPost, Path: "/password/reset", Tag: "password", Summary: "Reset the password with the emailed token",
+		Request: controllers.ResetPasswordRequest{}, Status: http.StatusOK, Response: controllers.MessageResponse{}, Problems: []int{http.StatusUnauthorized}},
+
+	// profile
+	{Method: http.MethodGet, Path: "/me", Tag: "profile", Summary: "Get the profile", Auth: true,
+		Status: http.StatusOK, Response: controllers.ProfileResponse{}},
+	{Method: http.MethodPatch, Path: "/me", Tag: "profile", Summary: "Update the profile", Auth: true,
+		Request: controllers.UpdateProfileRequest{}, Status: http.StatusOK, Response: controllers.ProfileResponse{}},
+	{Method: http.MethodDelete, Path: "/me", Tag: "profile", Summary: "Delete the account, the owned organizations are handed over or deleted", Auth: true,
+		Request: controllers.DeleteAccountRequest{}, Status: http.StatusOK, Response: controllers.MessageResponse{}},
+	{Method: http.MethodPost, Path: "/me/password", Tag: "profile", Summary: "Change the password and revoke the other sessions", Auth: true,
+		Request: controllers.ChangePasswordRequest{}, Status: http.StatusOK, Response: controllers.ChangePasswordResponse{}},
+
+	// sessions
+	{Method: http.MethodGet, Path: "/me/sessions", Tag: "sessions", Summary: "List the active sessions", Auth: true,
+		Status: http.StatusOK, Response: []controllers.SessionResponse{}},
+	{Method: http.MethodGet, Path: "/me/sessions/:id", Tag: "sessions", Summary: "Get a session", Auth: true,
+		Status: http.StatusOK, Response: controllers.SessionResponse{}},
+	{Method: http.MethodDelete, Path: "/me/sessions/:id", Tag: "sessions", Summary: "Revoke a session", Auth: true,
+		Status: http.StatusOK, Response: controllers.MessageResponse{}},
+	{Method: http.MethodPost, Path: "/logout-all", Tag: "sessions", Summary: "Revoke all the sessions", Auth: true,
+		Status: http.StatusOK, Response: controllers.LogoutAllResponse{}},
+
+	// mfa
+	{Method: http.MethodPost, Path: "/me/mfa/enroll", Tag: "mfa", Summary: "Start the enrollment, the secret has to be confirmed", Auth: true,
+		Request: controllers.EnrollMFARequest{}, Status: http.StatusOK, Response: controllers.EnrollMFAResponse{}, Problems: []int{http.StatusConflict}},
+	{Method: http.MethodPost, Path: "/me/mfa/confirm", Tag: "mfa", Summary: "Confirm the enrollment with a code and get the recovery codes", Auth: true,
+		Request: controllers.MFACodeRequest{}, Status: http.StatusOK, Response: controllers.RecoveryCodesResponse{}, Problems: []int{http.StatusConflict}},
+	{Method: http.MethodPost, Path: "/me/mfa/disable", Tag: "mfa", Summary: "Disable the two factor authentication", Auth: true,
+		Request: controllers.DisableMFARequest{}, Status: http.StatusOK, Response: controllers.MessageResponse{}},
+	{Method: http.MethodPost, Path: "/me/mfa/recovery-codes", Tag: "mfa", Summary: "Replace the recovery codes", Auth: true,
+		Request: controllers.MFACodeRequest{}, Status: http.StatusOK, Response: controllers.RecoveryCodesResponse{}},
+
+	// organizations
+	{Method: http.MethodPost, Path: "/organizations/", Tag: "organizations", Summary: "Create an organization owned by the user", Auth: true,
+		Request: controllers.CreateOrganizationRequest{}, Status: http.StatusCreated, Response: controllers.OrganizationIDResponse{}},
+	{Method: http.MethodGet, Path: "/organizations/", Tag: "organizations", Summary: "List the organizations of the user", Auth: true,
+		Query: controllers.ListOrganizationsRequest{}, Status: http.StatusOK, Response: controllers.ListOrganizationsResponse{}},
+	{Method: http.MethodGet, Path: "/organizations/:id", Tag: "organizations", Summary: "Get an organization", Auth: true,
+		Status: http.StatusOK, Response: controllers.OrganizationResponse{}, Problems: []int{http.StatusForbidden}},
+	{Method: http.MethodPut, Path: "/organizations/:id", Tag: "organizations", Summary: "Update an organization", Auth: true,
+		Request: controllers.UpdateOrganizationRequest{}, Status: http.StatusOK, Response: controllers.UpdateOrganizationResponse{}, Problems: []int{http.StatusForbidden}},
+	{Method: http.MethodDelete, Path: "/organizations/:id", Tag: "organizations", Summary: "Delete an organization", Auth: true,
+		Status: http.StatusOK, Response: controllers.MessageResponse{}, Problems: []int{http.StatusForbidden}},
+	{Method: http.MethodPost, Path: "/organizations/:id/invite", Tag: "organizations", Summary: "Invite a user to an organization", Auth: true,
+		Request: controllers.InviteUserToOrganizationRequest{}, Status: http.StatusCreated, Response: controllers.InviteUserToOrganizationResponse{},
+		Problems: []int{http.StatusForbidden, http.StatusConflict}},
+	{Method: http.MethodPost, Path: "/organizations/:id/leave", Tag: "organizations", Summary: "Leave an organization", Auth: true,
+		Status: http.StatusOK, Response: controllers.MessageResponse{}, Problems: []int{http.StatusConflict}},
+	{Method: http.MethodPut, Path: "/organizations/:id/members/:member_id", Tag: "organizations", Summary: "Change the access level of a member", Auth: true,
+		Request: controllers.UpdateMemberAccessLevelRequest{}, Status: http.StatusOK, Response: controllers.UpdateMemberAccessLevelResponse{},
+		Problems: []int{http.StatusForbidden, http.StatusConflict}},
+	{Method: http.MethodDelete, Path: "/organizations/:id/members/:member_id", Tag: "organizations", Summary: "Remove a member", Auth: true,
+		Status: http.StatusOK, Response: controllers.MessageResponse{}, Problems: []int{http.StatusForbidden, http.StatusConflict}},
+	{Method: http.MethodPost, Path: "/organizations/:id/transfer", Tag: "organizations", Summary: "Propose the ownership to a member", Auth: true,
+		Request: controllers.TransferOwnershipRequest{}, Status: http.StatusCreated, Response: controllers.TransferOwnershipResponse{}, Problems: []int{http.StatusForbidden}},
+	{Method: http.MethodPost, Path: "/organizations/:id/transfer/accept", Tag: "organizations", Summary: "Accept the proposed ownership", Auth: true,
+		Status: http.StatusOK, Response: controllers.OrganizationIDResponse{}, Problems: []int{http.StatusGone}},
+	{Method: http.MethodDelete, Path: "/organizations/:id/transfer", Tag: "organizations", Summary: "Cancel or decline the proposed ownership", Auth: true,
+		Status: http.StatusOK, Response: controllers.MessageResponse{}},
+
+	// invitations
+	{Method: http.MethodGet, Path: "/invitations/", Tag: "invitations", Summary: "List the pending invitations of the user", Auth: true,
+		Status: http.StatusOK, Response: []models.Invitation{}},
+	{Method: http.MethodPost, Path: "/invitations/accept", Tag: "invitations", Summary: "Accept an invitation with its token", Auth: true,
+		Request: controllers.AcceptInvitationByTokenRequest{}, Status: http.StatusOK, Response: controllers.AcceptInvitationResponse{},
+		Problems: []int{http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusGone}},
+	{Method: http.MethodPost, Path: "/invitations/:id/accept", Tag: "invitations", Summary: "Accept an invitation", Auth: true,
+		Status: http.StatusOK, Response: controllers.AcceptInvitationResponse{}, Problems: []int{http.StatusForbidden, http.StatusConflict, http.StatusGone}},
+	{Method: http.MethodPost, Path: "/invitations/:id/decline", Tag: "invitations", Summary: "Decline an invitation", Auth: true,
+		Status: http.StatusOK, Response: controllers.MessageResponse{}, Problems: []int{http.StatusConflict, http.StatusGone}},
+}
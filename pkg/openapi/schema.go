@@ -0,0 +1,200 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the prefix of the references to the schemas of the components
+const refPrefix = "#/components/schemas/"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// the builder of the schemas, the structs are added once to the components and referenced everywhere else
+type schemaBuilder struct {
+	schemas map[string]*Schema
+	types   map[string]reflect.Type
+	errs    []error
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{
+		schemas: map[string]*Schema{},
+		types:   map[string]reflect.Type{},
+	}
+}
+
+// the schema of a value, request tells if it's sent by the clients (the binding tags decide what is required)
+// or answered by the api (the fields without omitempty are always there)
+func (b *schemaBuilder) schemaOf(value any, request bool) *Schema {
+	return b.schema(reflect.TypeOf(value), request)
+}
+
+func (b *schemaBuilder) schema(t reflect.Type, request bool) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Uint, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer"}
+	case reflect.Int32, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: b.schema(t.Elem(), request)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schema(t.Elem(), request)}
+	case reflect.Struct:
+		return b.ref(t, request)
+	}
+
+	// the interfaces can hold anything
+	return &Schema{}
+}
+
+// add the struct to the components (once) and reference it
+func (b *schemaBuilder) ref(t reflect.Type, request bool) *Schema {
+	name := t.Name()
+	if existing, ok := b.types[name]; ok {
+		if existing != t {
+			b.errs = append(b.errs, fmt.Errorf("the schema %s is defined by both %s and %s", name, existing, t))
+		}
+		return &Schema{Ref: refPrefix + name}
+	}
+
+	// registered before the fields so the recursive types end
+	b.types[name] = t
+	b.schemas[name] = b.object(t, request)
+	return &Schema{Ref: refPrefix + name}
+}
+
+func (b *schemaBuilder) object(t reflect.Type, request bool) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, fs, required, ok := b.field(t, field, "json", request)
+		if !ok {
+			continue
+		}
+		s.Properties[name] = fs
+		if required {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+// the query parameters bound from the form tags of a request
+func (b *schemaBuilder) parameters(value any) []Parameter {
+	t := reflect.TypeOf(value)
+	params := []Parameter{}
+	for i := 0; i < t.NumField(); i++ {
+		name, fs, required, ok := b.field(t, t.Field(i), "form", true)
+		if !ok {
+			continue
+		}
+		params = append(params, Parameter{Name: name, In: "query", Required: required, Schema: fs})
+	}
+	return params
+}
+
+// the name, the schema and whether a field is required, ok is false for the fields that aren't encoded
+func (b *schemaBuilder) field(parent reflect.Type, field reflect.StructField, tag string, request bool) (string, *Schema, bool, bool) {
+	if !field.IsExported() {
+		return "", nil, false, false
+	}
+	name, opts, _ := strings.Cut(field.Tag.Get(tag), ",")
+	if name == "-" {
+		return "", nil, false, false
+	}
+	if name == "" {
+		name = field.Name
+	}
+
+	fs := b.schema(field.Type, request)
+	rules := field.Tag.Get("binding")
+	if rules == "" {
+		// without validation the requests fields are optional, the responses always have the fields that aren't omitted
+		return name, fs, !request && !slices.Contains(strings.Split(opts, ","), "omitempty"), true
+	}
+	return name, fs, b.applyBinding(parent, fs, rules, tag), true
+}
+
+// translate the validation rules of gin (binding tag) to the schema, it tells if the field is required
+func (b *schemaBuilder) applyBinding(parent reflect.Type, s *Schema, rules, tag string) bool {
+	required := false
+	for _, rule := range strings.Split(rules, ",") {
+		key, param, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = true
+		case "required_without":
+			s.Description = "required without " + fieldName(parent, param, tag)
+		case "email":
+			s.Format = "email"
+		case "numeric":
+			s.Pattern = "^[0-9]+$"
+		case "oneof":
+			s.Enum = strings.Fields(param)
+		case "len", "min", "max":
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				b.errs = append(b.errs, fmt.Errorf("the rule %s of %s isn't supported", rule, parent))
+				continue
+			}
+			b.applyBound(s, key, n)
+		}
+	}
+	return required
+}
+
+// the bounds are the length of the strings and the value of the numbers
+func (b *schemaBuilder) applyBound(s *Schema, key string, n int) {
+	switch s.Type {
+	case "string":
+		if key == "len" || key == "min" {
+			s.MinLength = &n
+		}
+		if key == "len" || key == "max" {
+			s.MaxLength = &n
+		}
+	case "integer", "number":
+		value := float64(n)
+		if key == "len" || key == "min" {
+			s.Minimum = &value
+		}
+		if key == "len" || key == "max" {
+			s.Maximum = &value
+		}
+	}
+}
+
+// the encoded name of the field named in a rule
+func fieldName(parent reflect.Type, goName, tag string) string {
+	field, ok := parent.FieldByName(goName)
+	if !ok {
+		return goName
+	}
+	name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+	if name == "" || name == "-" {
+		return goName
+	}
+	return name
+}
@@ -1,35 +1,54 @@
 package pkg
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	types "github.com/ayehia0/org/pkg/api"
 	"github.com/ayehia0/org/pkg/api/handlers"
 	api "github.com/ayehia0/org/pkg/api/middleware"
 	"github.com/ayehia0/org/pkg/api/routes"
+	"github.com/ayehia0/org/pkg/database"
 	"github.com/ayehia0/org/pkg/database/mongodb"
 	"github.com/ayehia0/org/pkg/database/redis"
+	"github.com/ayehia0/org/pkg/logger"
+	"github.com/ayehia0/org/pkg/mailer"
+	"github.com/ayehia0/org/pkg/metrics"
+	"github.com/ayehia0/org/pkg/openapi"
 	"github.com/ayehia0/org/pkg/token"
+	"github.com/ayehia0/org/pkg/tracing"
 	"github.com/ayehia0/org/pkg/utils"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type Server struct {
-	MongoDBConn *mongodb.MongoDBConn
-	RedisConn   *redis.RedisConn
+	Driver      database.Driver
 	DBConfig    *utils.DatabaseConfig
 	AppConfig   *utils.AppConfig
 	RedisConfig *utils.RedisConfig
 	Router      *gin.Engine
 	DBStore     *mongodb.DBStore
 	RedisStore  *redis.RedisStore
+	Logger      *slog.Logger
+
+	// flushes the pending spans on shutdown
+	shutdownTracing func(context.Context) error
 }
 
 func NewServer() *Server {
 	return &Server{}
 }
 
-func (s *Server) Init() error {
+// load the configs and connect to the storage backend
+func (s *Server) Open() error {
 	// the configs
 	dbConfig, redisConfig, appConfig, err := utils.ConfigStore("./config", "database-config", "redis-config", "app-config")
 
@@ -41,26 +60,49 @@ func (s *Server) Init() error {
 	s.AppConfig = &appConfig
 	s.RedisConfig = &redisConfig
 
-	// connect to the database
-	uri := fmt.Sprintf("mongodb://%s:%d", s.DBConfig.Host, s.DBConfig.Port)
-	dbConn, err := mongodb.NewMongoDBConn(uri, s.DBConfig.Database, s.DBConfig.Username, s.DBConfig.Password)
+	// the logger is the default one so the packages without an AppC log the same way
+	s.Logger = logger.New(s.AppConfig.Log)
+	slog.SetDefault(s.Logger)
+
+	// connect to the storage backend chosen by the database config
+	driver, err := database.Open(s.DBConfig, s.RedisConfig)
 	if err != nil {
 		return err
 	}
+	s.Driver = driver
+	return nil
+}
 
-	// connect to the redis
-	redisDb := 0 // the default db
-	redisConn, err := redis.NewRedisConn(s.RedisConfig.Host, s.RedisConfig.Port, s.RedisConfig.Password, redisDb)
+func (s *Server) Init() error {
+	if err := s.Open(); err != nil {
+		return err
+	}
 
-	s.MongoDBConn = dbConn
-	s.RedisConn = redisConn
+	// bring the database up to date before serving
+	if s.DBConfig.AutoMigrate {
+		if _, err := s.Driver.Migrate(context.Background()); err != nil {
+			return err
+		}
+	}
 
-	// setup the engine
-	s.Router = gin.Default()
+	// export the spans according to the tracing config
+	shutdownTracing, err := tracing.Setup(context.Background(), s.AppConfig.Tracing)
+	if err != nil {
+		return err
+	}
+	s.shutdownTracing = shutdownTracing
+
+	// disable the debug mode (and its route listing) in production
+	if s.AppConfig.Env == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	gin.DebugPrintRouteFunc = func(method, path, handler string, handlers int) {
+		s.Logger.Debug("route", "method", method, "path", path, "handler", handler)
+	}
 
 	// defining the repositories
-	s.DBStore = mongodb.NewStore(s.MongoDBConn)
-	s.RedisStore = redis.NewStore(s.RedisConn)
+	s.DBStore = s.Driver.DBStore()
+	s.RedisStore = s.Driver.RedisStore()
 
 	// create a token creator
 	tokenCreator, err := token.NewPasteoToken(s.AppConfig.JwtSecret)
@@ -69,42 +111,161 @@ func (s *Server) Init() error {
 		return err
 	}
 
+	// the emails are delivered according to the mail driver
+	mail, err := mailer.New(s.AppConfig.Mail, s.AppConfig.Env == "production")
+	if err != nil {
+		return err
+	}
+
 	appC := &types.AppC{
-		MongoDBConn:  s.MongoDBConn,
 		DBStore:      s.DBStore,
 		RDBStore:     s.RedisStore,
 		TokenCreator: tokenCreator,
 		AppConfig:    s.AppConfig,
+		Mailer:       mail,
+		HealthChecks: s.Driver.HealthChecks(),
+		Logger:       s.Logger,
+	}
+
+	s.Router, err = NewRouter(appC)
+	return err
+}
+
+// build the engine serving the api, the server and the tests share it
+func NewRouter(appC *types.AppC) (*gin.Engine, error) {
+	// setup the engine, every request gets an id, is measured, traced (except the probes) and logged
+	router := gin.New()
+	if err := router.SetTrustedProxies(appC.AppConfig.HTTP.TrustedProxies); err != nil {
+		return nil, err
 	}
+	router.Use(api.RequestIDMiddleware())
+	router.Use(api.MetricsMiddleware())
+	router.Use(otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(func(r *http.Request) bool {
+		return r.URL.Path != "/metrics" && r.URL.Path != "/healthz" && r.URL.Path != "/readyz"
+	})))
+	router.Use(api.AccessLogMiddleware(appC.Logger))
+	// the errors of the handlers (and the panics) are answered as problem details
+	router.Use(api.ErrorMiddleware(appC.Logger))
+	router.Use(api.RecoveryMiddleware(appC.Logger))
+	router.NoRoute(func(ctx *gin.Context) {
+		api.Abort(ctx, api.ErrRouteNotFound)
+	})
+	// the controllers pass the gin context down, it has to carry the span and the id of the request
+	router.ContextWithFallback = true
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	router.GET(openapi.DocumentPath, openapi.Handler())
+	router.GET(openapi.UIPath, openapi.UIHandler())
+	router.GET(openapi.AssetPath, openapi.AssetHandler())
 
 	orgHandler := handlers.NewOrgHandler(appC)
 	userHandler := handlers.NewUserHandler(appC)
+	invitationHandler := handlers.NewInvitationHandler(appC)
+	sessionHandler := handlers.NewSessionHandler(appC)
+	mfaHandler := handlers.NewMFAHandler(appC)
+	passwordHandler := handlers.NewPasswordHandler(appC)
+	profileHandler := handlers.NewProfileHandler(appC)
+	healthHandler := handlers.NewHealthHandler(appC)
 
-	routes.SetupUserRoutes(s.Router.Group("/"), userHandler)
+	routes.SetupHealthRoutes(router.Group("/"), healthHandler)
+
+	// the auth routes are rate limited per ip, per email and per user
+	limiter := appC.RDBStore.RateLimitRepository
+	limits := routes.AuthLimits{
+		Login:   api.RateLimitMiddleware(limiter, appC.TokenCreator, "login", appC.AppConfig.RateLimit.Login),
+		Signup:  api.RateLimitMiddleware(limiter, appC.TokenCreator, "signup", appC.AppConfig.RateLimit.Signup),
+		Refresh: api.RateLimitMiddleware(limiter, appC.TokenCreator, "refresh", appC.AppConfig.RateLimit.Refresh),
+		MFA:     api.RateLimitMiddleware(limiter, appC.TokenCreator, "mfa", appC.AppConfig.RateLimit.MFA),
+	}
+	routes.SetupUserRoutes(router.Group("/"), userHandler, limits)
+	routes.SetupPasswordRoutes(router.Group("/password"), passwordHandler)
 
 	// use authMiddleware to protect the routes
-	authRquired := s.Router.Group("/organizations")
-	authRquired.Use(api.AuthMiddleware(tokenCreator))
+	authMiddleware := api.AuthMiddleware(appC.TokenCreator, appC.RDBStore.RevocationRepository, appC.DBStore.SessionRepository)
+
+	authenticated := router.Group("/")
+	authenticated.Use(authMiddleware)
+
+	routes.SetupAuthenticatedUserRoutes(authenticated, userHandler)
+	routes.SetupProfileRoutes(authenticated, profileHandler)
+	routes.SetupSessionRoutes(authenticated, sessionHandler)
+	routes.SetupMFARoutes(authenticated, mfaHandler)
+
+	authRquired := router.Group("/organizations")
+	authRquired.Use(authMiddleware)
 
 	routes.SetupOrgRoutes(authRquired, orgHandler)
 
-	// the api
-	return nil
+	invitations := router.Group("/invitations")
+	invitations.Use(authMiddleware)
+
+	routes.SetupInvitationRoutes(invitations, invitationHandler)
+
+	return router, nil
 }
 
+// the time given to the in-flight requests when the shutdown timeout isn't configured
+const defaultShutdownTimeout = 10 * time.Second
+
 func (s *Server) Run() error {
 	// run the server
+	srv := &http.Server{
+		Addr:              fmt.Sprintf("0.0.0.0:%d", s.AppConfig.Port),
+		Handler:           s.Router,
+		ReadTimeout:       s.AppConfig.HTTP.ReadTimeout,
+		ReadHeaderTimeout: s.AppConfig.HTTP.ReadHeaderTimeout,
+		WriteTimeout:      s.AppConfig.HTTP.WriteTimeout,
+		IdleTimeout:       s.AppConfig.HTTP.IdleTimeout,
+	}
+
+	errs := make(chan error, 1)
 
 	// check if the server is running on production or development
 	if s.AppConfig.Env == "production" {
 		// run a ssl server using the certs issued by letsencrypt which is found on : /etc/letsencrypt/live/<domain-name>/{fullchain.pem, privkey.pem}
-		// disable debug mode
-		gin.SetMode(gin.ReleaseMode)
-		return s.Router.RunTLS(fmt.Sprintf("0.0.0.0:%d", s.AppConfig.Port),
-			"./fullchain.pem",
-			"./privkey.pem",
-		)
+		go func() {
+			errs <- srv.ListenAndServeTLS("./fullchain.pem", "./privkey.pem")
+		}()
 	} else {
-		return s.Router.Run(fmt.Sprintf("0.0.0.0:%d", s.AppConfig.Port))
+		go func() {
+			errs <- srv.ListenAndServe()
+		}()
+	}
+
+	// serve until the server fails or SIGINT/SIGTERM is received
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	s.Logger.Info("listening", "addr", srv.Addr, "env", s.AppConfig.Env)
+
+	select {
+	case err := <-errs:
+		return errors.Join(err, s.Close())
+	case <-ctx.Done():
+	}
+
+	// a second signal kills the process right away
+	stop()
+
+	// stop accepting connections and wait for the in-flight requests, then disconnect from the databases
+	timeout := s.AppConfig.HTTP.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	s.Logger.Info("shutting down", "timeout", timeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return errors.Join(srv.Shutdown(shutdownCtx), s.Close())
+}
+
+// disconnect from the databases and flush the pending spans
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultShutdownTimeout)
+	defer cancel()
+
+	err := s.Driver.Close(ctx)
+	if s.shutdownTracing != nil {
+		err = errors.Join(err, s.shutdownTracing(ctx))
 	}
+	return err
 }
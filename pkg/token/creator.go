@@ -1,10 +1,16 @@
 package token
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 type TokenCreator interface {
 	// create a token for a username/email with a duration time
-	Create(userId string, duration time.Duration) (string, *Payload, error)
+	Create(ctx context.Context, userId string, duration time.Duration) (string, *Payload, error)
+
+	// create a token that can only be used for a specific purpose like accepting an invitation
+	CreateWithPurpose(ctx context.Context, subject, purpose string, duration time.Duration) (string, *Payload, error)
 
 	// verify the token
 	Verify(token string) (*Payload, error)
@@ -1,11 +1,14 @@
 package token
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/aead/chacha20poly1305"
+	"github.com/ayehia0/org/pkg/tracing"
 	"github.com/o1egl/paseto"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
@@ -32,8 +35,15 @@ func NewPasteoToken(secretKey string) (TokenCreator, error) {
 	return creator, nil
 }
 
-func (p *PasteoCreator) Create(userId string, duration time.Duration) (string, *Payload, error) {
-	payload, err := NewPayload(userId, duration)
+func (p *PasteoCreator) Create(ctx context.Context, userId string, duration time.Duration) (string, *Payload, error) {
+	return p.CreateWithPurpose(ctx, userId, "", duration)
+}
+
+func (p *PasteoCreator) CreateWithPurpose(ctx context.Context, subject, purpose string, duration time.Duration) (string, *Payload, error) {
+	_, span := tracing.Start(ctx, "token.Create", attribute.String("token.purpose", purpose))
+	defer span.End()
+
+	payload, err := NewPayloadWithPurpose(subject, purpose, duration)
 	if err != nil {
 		return "", payload, err
 	}
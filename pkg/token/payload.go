@@ -1,25 +1,40 @@
 package token
 
 import (
-	"errors"
 	"time"
 
+	"github.com/ayehia0/org/pkg/apperror"
 	"github.com/google/uuid"
 )
 
 var (
-	TokenExpiredError = errors.New("Token has been expired!")
-	TokenInvalidError = errors.New("Token is invalid!")
+	TokenExpiredError = apperror.New(apperror.KindUnauthorized, "token_expired", "Token has been expired!")
+	TokenInvalidError = apperror.New(apperror.KindUnauthorized, "token_invalid", "Token is invalid!")
+)
+
+// the purposes a token can be issued for, the session tokens don't have a purpose
+const (
+	PurposeInvite  = "invite"
+	PurposeRefresh = "refresh"
+	PurposeMFA     = "mfa"
+	PurposeReset   = "password_reset"
+	PurposeVerify  = "email_verification"
 )
 
 type Payload struct {
 	Id        uuid.UUID `json:"id"`
 	UserId    string    `json:"user_id"`
+	Purpose   string    `json:"purpose,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	ExpiredAt time.Time `json:"expired_at"`
 }
 
 func NewPayload(userId string, duration time.Duration) (*Payload, error) {
+	return NewPayloadWithPurpose(userId, "", duration)
+}
+
+// create a payload that can only be used for the given purpose, the userId is the subject of the token
+func NewPayloadWithPurpose(userId, purpose string, duration time.Duration) (*Payload, error) {
 
 	tokenId, err := uuid.NewRandom()
 	if err != nil {
@@ -29,6 +44,7 @@ func NewPayload(userId string, duration time.Duration) (*Payload, error) {
 	payload := &Payload{
 		Id:        tokenId,
 		UserId:    userId,
+		Purpose:   purpose,
 		CreatedAt: time.Now(),
 		ExpiredAt: time.Now().Add(duration),
 	}
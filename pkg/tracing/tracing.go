@@ -0,0 +1,149 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ayehia0/org/pkg/buildinfo"
+	"github.com/ayehia0/org/pkg/metrics"
+	"github.com/ayehia0/org/pkg/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+/*
+The tracing package sets up the opentelemetry tracer of the application, the spans are exported according to the exporter of the tracing config:
+	- none: the spans are dropped (default)
+	- stdout: the spans are printed as json, or written to a file for the local runs
+	- otlp: the spans are sent to an otlp/http collector
+*/
+
+// the exporters of the spans
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// the name of the application in the traces
+const ServiceName = "organization-api"
+
+// the tracer used by the packages of the application
+var tracer = otel.Tracer("github.com/ayehia0/org")
+
+// setup the tracer provider and the propagation of the trace context, call the returned function on shutdown to flush the pending spans
+func Setup(ctx context.Context, config utils.TracingConfig) (func(context.Context) error, error) {
+	// the trace context is propagated even if the spans aren't exported
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, closer, err := newExporter(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(ServiceName),
+		semconv.ServiceVersion(buildinfo.Get().Version),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	// sample everything unless a ratio is given, the sampling decision of the caller is respected
+	sampler := sdktrace.ParentBased(sdktrace.AlwaysSample())
+	if config.SampleRatio > 0 && config.SampleRatio < 1 {
+		sampler = sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sampler),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			if cerr := closer.Close(); err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}, nil
+}
+
+// create the exporter of the spans, the closer is the file the spans are written to if any
+func newExporter(ctx context.Context, config utils.TracingConfig) (sdktrace.SpanExporter, io.Closer, error) {
+	switch config.Exporter {
+	case "", ExporterNone:
+		return nil, nil, nil
+
+	case ExporterStdout:
+		if config.File == "" {
+			exporter, err := stdouttrace.New()
+			return exporter, nil, err
+		}
+		file, err := os.OpenFile(config.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		return exporter, file, nil
+
+	case ExporterOTLP:
+		// the endpoint can also be given by OTEL_EXPORTER_OTLP_ENDPOINT
+		var opts []otlptracehttp.Option
+		if config.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(config.Endpoint))
+		}
+		if config.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(ctx, opts...)
+		return exporter, nil, err
+	}
+
+	return nil, nil, fmt.Errorf("unknown tracing exporter %q", config.Exporter)
+}
+
+// start a span, the span is a child of the span found in the context
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// start a span for a database operation and time it, call the returned function once it's done:
+//
+//	ctx, end := tracing.StartDB(ctx, "mongodb", "users", "FindByID")
+//	defer end()
+func StartDB(ctx context.Context, system, collection, method string) (context.Context, func()) {
+	observe := metrics.ObserveDB(collection, method)
+	ctx, span := tracer.Start(ctx, collection+"."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemKey.String(system),
+			attribute.String("db.collection.name", collection),
+			semconv.DBOperation(method),
+		),
+	)
+	return ctx, func() {
+		span.End()
+		observe()
+	}
+}
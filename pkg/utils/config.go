@@ -16,15 +16,104 @@ type DatabaseConfig struct {
 	Database string `mapstructure:"database"` // the name of the database
 	Username string `mapstructure:"username"` // the username for the database
 	Password string `mapstructure:"password"` // the password for the database
+	SSLMode  string `mapstructure:"sslmode"`  // the ssl mode of the postgres connection (disable by default)
+
+	AutoMigrate bool `mapstructure:"autoMigrate"` // apply the pending migrations on startup, otherwise run the migrate command
 }
 
 // the app config contains related configurations for the app
 type AppConfig struct {
-	Port                   int           `mapstructure:"aport" env:"APORT"`
-	Env                    string        `mapstructure:"env" env:"ENV"`
-	JwtSecret              string        `mapstructure:"jwtSecret"`
-	TokenAccessExpiration  time.Duration `mapstructure:"tokenAccessExpiration"`
-	TokenRefreshExpiration time.Duration `mapstructure:"tokenRefreshExpiration"`
+	Port                    int           `mapstructure:"aport" env:"APORT"`
+	Env                     string        `mapstructure:"env" env:"ENV"`
+	JwtSecret               string        `mapstructure:"jwtSecret"`
+	TokenAccessExpiration   time.Duration `mapstructure:"tokenAccessExpiration"`
+	TokenRefreshExpiration  time.Duration `mapstructure:"tokenRefreshExpiration"`
+	InvitationExpiration    time.Duration `mapstructure:"invitationExpiration"`
+	TransferExpiration      time.Duration `mapstructure:"transferExpiration"`
+	MFAChallengeExpiration  time.Duration `mapstructure:"mfaChallengeExpiration"`
+	MFAIssuer               string        `mapstructure:"mfaIssuer"`
+	PasswordResetExpiration time.Duration `mapstructure:"passwordResetExpiration"`
+
+	// the email verification, the unverified users can be kept out of the login and the organizations
+	EmailVerificationExpiration time.Duration `mapstructure:"emailVerificationExpiration"`
+	RequireVerifiedLogin        bool          `mapstructure:"requireVerifiedLogin"`
+	RequireVerifiedMembership   bool          `mapstructure:"requireVerifiedMembership"`
+
+	// how long the readiness probe waits for each dependency
+	ReadinessTimeout time.Duration `mapstructure:"readinessTimeout"`
+
+	Mail    MailConfig    `mapstructure:"mail"`
+	HTTP    HTTPConfig    `mapstructure:"http"`
+	Tracing TracingConfig `mapstructure:"tracing"`
+	Log     LogConfig     `mapstructure:"log"`
+
+	// the brute force protection of the auth endpoints
+	RateLimit RateLimitConfig `mapstructure:"rateLimit"`
+	Lockout   LockoutConfig   `mapstructure:"lockout"`
+}
+
+// the http config contains the timeouts of the http server, a zero timeout means no timeout
+type HTTPConfig struct {
+	ReadTimeout       time.Duration `mapstructure:"readTimeout"`
+	ReadHeaderTimeout time.Duration `mapstructure:"readHeaderTimeout"`
+	WriteTimeout      time.Duration `mapstructure:"writeTimeout"`
+	IdleTimeout       time.Duration `mapstructure:"idleTimeout"`
+	ShutdownTimeout   time.Duration `mapstructure:"shutdownTimeout"` // how long the in-flight requests have to finish on shutdown
+	TrustedProxies    []string      `mapstructure:"trustedProxies"`  // the proxies allowed to set X-Forwarded-For, the client ip is the remote address otherwise
+}
+
+// the tracing config contains where the spans are exported
+type TracingConfig struct {
+	Exporter    string  `mapstructure:"exporter"`    // none, stdout or otlp
+	File        string  `mapstructure:"file"`        // the file the stdout exporter writes to instead of the stdout
+	Endpoint    string  `mapstructure:"endpoint"`    // the host:port of the otlp/http collector
+	Insecure    bool    `mapstructure:"insecure"`    // send the spans to the collector without tls
+	SampleRatio float64 `mapstructure:"sampleRatio"` // the ratio of the traces kept, everything is kept by default
+}
+
+// the rate limits of the auth endpoints
+type RateLimitConfig struct {
+	Login   RateLimitRule `mapstructure:"login"`
+	Signup  RateLimitRule `mapstructure:"signup"`
+	Refresh RateLimitRule `mapstructure:"refresh"`
+	MFA     RateLimitRule `mapstructure:"mfa"`
+}
+
+// the limits of an endpoint, the requests are counted per ip, per email (of the body) and per user (of the token)
+type RateLimitRule struct {
+	IP    RateLimit `mapstructure:"ip"`
+	Email RateLimit `mapstructure:"email"`
+	User  RateLimit `mapstructure:"user"`
+}
+
+// at most limit requests in any window, a zero limit disables it
+type RateLimit struct {
+	Limit  int           `mapstructure:"limit"`
+	Window time.Duration `mapstructure:"window"`
+}
+
+// the account is locked for duration after maxFailures wrong passwords within window, a zero maxFailures disables it
+type LockoutConfig struct {
+	MaxFailures int           `mapstructure:"maxFailures"`
+	Window      time.Duration `mapstructure:"window"`
+	Duration    time.Duration `mapstructure:"duration"`
+}
+
+// the log config contains how the logs are written to the stdout
+type LogConfig struct {
+	Level  string `mapstructure:"level"`  // debug, info, warn or error
+	Format string `mapstructure:"format"` // json (default) or text
+}
+
+// the mail config contains the configurations for delivering the emails
+type MailConfig struct {
+	Driver   string `mapstructure:"driver"` // smtp, file or memory
+	From     string `mapstructure:"from"`
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	Dir      string `mapstructure:"dir"` // where the file driver writes the emails
 }
 
 // the redis config contains related configurations for the redis